@@ -0,0 +1,170 @@
+// Package bankdir provides directories of the 全銀協 金融機関・店舗コード list for the parser,
+// see zengin.WithBankDirectory.
+//
+// The package doesn't ship the full list, which is large and changes often: it is
+// loaded at run time from the zengin-code JSON or CSV dumps (https://github.com/zengin-code/source-data)
+// with LoadJSON or LoadCSV, or from a copy embedded by the application with go:embed.
+// Only such a directory rejects unknown bank and branch codes.
+//
+// MajorBanks is a small list of 14 major banks without their branches, for tests and examples.
+// With it, the codes of other banks, such as 信用金庫 and 信用組合, are only reported as warnings
+// and branch codes are not checked.
+package bankdir
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strings"
+)
+
+//go:embed banks.csv
+var embedded string
+
+// Directory is an in-memory types.BankDirectory
+type Directory struct {
+	banks    map[string]types.Bank
+	branches map[string]map[string]types.Branch // by bank code, then branch code
+	partial  bool
+}
+
+// New returns an empty directory
+func New() *Directory {
+	return &Directory{banks: map[string]types.Bank{}, branches: map[string]map[string]types.Branch{}}
+}
+
+// MajorBanks returns a partial directory of the 14 major banks embedded in the package, without their branches,
+// see SetPartial. It is not a snapshot of the 全銀協 list, which LoadJSON and LoadCSV read.
+func MajorBanks() *Directory {
+	d, err := LoadCSV(strings.NewReader(embedded))
+	if err != nil {
+		panic(err)
+	}
+	d.SetPartial(true)
+	return d
+}
+
+// SetPartial marks the directory as missing some banks, so that unknown bank codes are warnings instead of errors
+func (d *Directory) SetPartial(partial bool) {
+	d.partial = partial
+}
+
+// Partial reports whether the directory is missing some banks, see types.PartialDirectory
+func (d *Directory) Partial() bool {
+	return d.partial
+}
+
+func (d *Directory) Bank(code string) (types.Bank, bool) {
+	bank, ok := d.banks[code]
+	return bank, ok
+}
+
+func (d *Directory) Branch(bankCode, branchCode string) (types.Branch, bool) {
+	branch, ok := d.branches[bankCode][branchCode]
+	return branch, ok
+}
+
+// AddBank adds a bank or replaces it, keeping its branches. Kana is converted to Zengin half-width kana by kana.Normalize.
+func (d *Directory) AddBank(bank types.Bank) {
+	bank.Kana = kana.Normalize(bank.Kana)
+	bank.BranchCount = len(d.branches[bank.Code])
+	d.banks[bank.Code] = bank
+}
+
+// AddBranch adds a branch or replaces it. Kana is converted to Zengin half-width kana by kana.Normalize.
+func (d *Directory) AddBranch(branch types.Branch) {
+	branch.Kana = kana.Normalize(branch.Kana)
+	if d.branches[branch.BankCode] == nil {
+		d.branches[branch.BankCode] = map[string]types.Branch{}
+	}
+	d.branches[branch.BankCode][branch.Code] = branch
+	if bank, ok := d.banks[branch.BankCode]; ok {
+		bank.BranchCount = len(d.branches[branch.BankCode])
+		d.banks[branch.BankCode] = bank
+	}
+}
+
+// jsonEntry is a bank or a branch in the zengin-code JSON dumps
+type jsonEntry struct {
+	Code     string               `json:"code"`
+	Name     string               `json:"name"`
+	Kana     string               `json:"kana"`
+	Branches map[string]jsonEntry `json:"branches"`
+}
+
+// LoadJSON returns a directory of the banks in a zengin-code banks.json dump, an object keyed by bank code.
+// Banks may hold their branches in a "branches" object keyed by branch code.
+func LoadJSON(r io.Reader) (*Directory, error) {
+	var banks map[string]jsonEntry
+	if err := json.NewDecoder(r).Decode(&banks); err != nil {
+		return nil, fmt.Errorf("invalid banks JSON: %w", err)
+	}
+	d := New()
+	for code, bank := range banks {
+		d.AddBank(types.Bank{Code: code, Name: bank.Name, Kana: bank.Kana})
+		for branchCode, branch := range bank.Branches {
+			d.AddBranch(types.Branch{BankCode: code, Code: branchCode, Name: branch.Name, Kana: branch.Kana})
+		}
+	}
+	return d, nil
+}
+
+// LoadBranchesJSON adds the branches of a bank from a zengin-code branches/<bank code>.json dump
+func (d *Directory) LoadBranchesJSON(bankCode string, r io.Reader) error {
+	var branches map[string]jsonEntry
+	if err := json.NewDecoder(r).Decode(&branches); err != nil {
+		return fmt.Errorf("invalid branches JSON: %w", err)
+	}
+	for code, branch := range branches {
+		d.AddBranch(types.Branch{BankCode: bankCode, Code: code, Name: branch.Name, Kana: branch.Kana})
+	}
+	return nil
+}
+
+// LoadCSV returns a directory from a CSV file with a header row naming the columns
+// bank_code, branch_code, bank_name, bank_kana, branch_name and branch_kana.
+// Only bank_code is required, and rows without a branch code add the bank only.
+func LoadCSV(r io.Reader) (*Directory, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid banks CSV: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["bank_code"]; !ok {
+		return nil, errors.New("invalid banks CSV: no bank_code column")
+	}
+
+	d := New()
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return d, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid banks CSV: %w", err)
+		}
+		column := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		bankCode := column("bank_code")
+		if _, ok := d.banks[bankCode]; !ok || column("bank_name") != "" {
+			d.AddBank(types.Bank{Code: bankCode, Name: column("bank_name"), Kana: column("bank_kana")})
+		}
+		if branchCode := column("branch_code"); branchCode != "" {
+			d.AddBranch(types.Branch{BankCode: bankCode, Code: branchCode, Name: column("branch_name"), Kana: column("branch_kana")})
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package bankdir_test
+
+import (
+	"github.com/Kyash/zengin-go/bankdir"
+	"strings"
+	"testing"
+)
+
+func TestLoadCSV(t *testing.T) {
+	directory, err := bankdir.LoadCSV(strings.NewReader(`bank_code,branch_code,bank_name,bank_kana,branch_name,branch_kana
+2606,010,兵庫県信用組合,ヒヨウゴケンシンクミ,本店,ホンテン
+2606,020,兵庫県信用組合,ヒヨウゴケンシンクミ,兵庫,ヒヨウゴ
+0005,,三菱ＵＦＪ,ミツビシユーエフジエイ,,
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bank, ok := directory.Bank("2606"); !ok || bank.Kana != "ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ" || bank.BranchCount != 2 {
+		t.Fatalf("expected 兵庫県信用組合 with two branches, got %+v", bank)
+	}
+	if branch, ok := directory.Branch("2606", "020"); !ok || branch.Name != "兵庫" || branch.Kana != "ﾋﾖｳｺﾞ" {
+		t.Fatalf("expected the 兵庫 branch, got %+v", branch)
+	}
+	if bank, ok := directory.Bank("0005"); !ok || bank.BranchCount != 0 {
+		t.Fatalf("expected a bank without branches, got %+v", bank)
+	}
+	if _, ok := directory.Branch("2606", "030"); ok || directory.Partial() {
+		t.Fatal("expected a loaded directory to be complete")
+	}
+
+	if _, err := bankdir.LoadCSV(strings.NewReader("code,name\n0005,三菱ＵＦＪ\n")); err == nil {
+		t.Fatal("expected error for a CSV without a bank_code column")
+	}
+}
+
+func TestMajorBanks(t *testing.T) {
+	directory := bankdir.MajorBanks()
+	if !directory.Partial() {
+		t.Fatal("expected the embedded directory to be partial")
+	}
+	if bank, ok := directory.Bank("0005"); !ok || bank.Kana != "ﾐﾂﾋﾞｼﾕ-ｴﾌｼﾞｴｲ" {
+		t.Fatalf("expected 三菱UFJ in the embedded directory, got %+v", bank)
+	}
+}
@@ -0,0 +1,260 @@
+// Package calendar tells the business days of Japanese banks, see zengin.WithCalendar and Encoder.SetCalendar.
+//
+// Banks are closed on weekends, on the national holidays (国民の祝日) with their 振替休日 and 国民の休日,
+// and from December 31 to January 3. The package embeds the holidays of 2016 to 2027, copied from the 内閣府 list
+// (syukujitsu.csv). A calendar covers the years of its list and the years 2007 to 2099, whose holidays are
+// computed by the rules of the 国民の祝日に関する法律 when they are not in the list, see Calendar.Covers.
+// Dates in other years are errors wrapping types.ErrDateNotCovered, and Load reads the full list for earlier years.
+package calendar
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"golang.org/x/text/encoding/japanese"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed holidays.csv
+var embedded string
+
+// Calendar is a types.Calendar of Japanese banks
+type Calendar struct {
+	holidays map[int]map[monthDay]string // by year, for the years of the list
+}
+
+// Japan returns the calendar of the holidays of 2016 to 2027 embedded in the package.
+// Like any calendar it covers the years 2007 to 2099, computing the holidays of the years out of the list.
+func Japan() *Calendar {
+	c, err := Load(strings.NewReader(embedded))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Load returns the calendar of a 内閣府 list of holidays, such as the latest syukujitsu.csv
+// from https://www8.cao.go.jp/chosei/shukujitsu/gaiyou.html, in Shift-JIS or UTF-8.
+// Its first line is a header, and every other line a YYYY/M/D date and the name of the holiday.
+// The calendar covers the years of the list and the years 2007 to 2099, see Covers.
+func Load(r io.Reader) (*Calendar, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(content) {
+		if content, err = japanese.ShiftJIS.NewDecoder().Bytes(content); err != nil {
+			return nil, err
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	c := &Calendar{holidays: map[int]map[monthDay]string{}}
+	for i, row := range rows {
+		if i == 0 || len(row) == 0 || row[0] == "" {
+			continue // header or blank line
+		}
+		date, err := time.Parse("2006/1/2", row[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date: %w", i+1, err)
+		}
+		name := ""
+		if len(row) > 1 {
+			name = row[1]
+		}
+		if c.holidays[date.Year()] == nil {
+			c.holidays[date.Year()] = map[monthDay]string{}
+		}
+		c.holidays[date.Year()][key(date)] = name
+	}
+	return c, nil
+}
+
+// IsBusinessDay reports whether banks are open on the day of date.
+// Only weekends and December 31 to January 3 are closed in the years the calendar doesn't cover.
+func (c *Calendar) IsBusinessDay(date time.Time) bool {
+	switch date.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	switch _, month, day := date.Date(); {
+	case month == time.December && day == 31, month == time.January && day <= 3:
+		return false
+	}
+	_, holiday := c.Holiday(date)
+	return !holiday
+}
+
+// NextBusinessDay returns the day of date if it is a business day, otherwise the first business day after it,
+// such as the day a transfer requested on a holiday is made
+func (c *Calendar) NextBusinessDay(date time.Time) time.Time {
+	for !c.IsBusinessDay(date) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// AddBusinessDays returns the nth business day after date, or before it if n is negative.
+// Date itself is returned for 0, whether it is a business day or not.
+func (c *Calendar) AddBusinessDays(date time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		date = date.AddDate(0, 0, step)
+		if c.IsBusinessDay(date) {
+			n--
+		}
+	}
+	return date
+}
+
+// Covers reports whether the holidays of the year of date are known: the years of the list, and the years
+// 2007 to 2099, which follow the 振替休日 and 国民の休日 rules of the 国民の祝日に関する法律 as amended in 2005.
+// For Japan, these are the embedded years 2016 to 2027 and the computed years 2007 to 2015 and 2028 to 2099.
+func (c *Calendar) Covers(date time.Time) bool {
+	year := date.Year()
+	_, ok := c.holidays[year]
+	return ok || year >= minRuleYear && year <= maxRuleYear
+}
+
+// Holiday returns the name of the national holiday on the day of date, or false if it is not a holiday
+// or the calendar doesn't cover its year. The name is 休日 for a 振替休日 or a 国民の休日.
+// Years not in the list are computed from the 国民の祝日に関する法律 as amended up to 2021,
+// unless the law changes again.
+func (c *Calendar) Holiday(date time.Time) (string, bool) {
+	if !c.Covers(date) {
+		return "", false
+	}
+	year, month, day := date.Date()
+	days, ok := c.holidays[year]
+	if !ok {
+		days = holidays(year)
+	}
+	name, ok := days[monthDay{month, day}]
+	return name, ok
+}
+
+// The years in which the rules of holidays hold
+const (
+	minRuleYear = 2007 // 振替休日 after any Sunday holiday, 昭和の日
+	maxRuleYear = 2099 // approximation of the equinoxes
+)
+
+type monthDay struct {
+	month time.Month
+	day   int
+}
+
+// holidays computes the national holidays of a year, with their 振替休日 and 国民の休日
+func holidays(year int) map[monthDay]string {
+	days := map[monthDay]string{}
+	add := func(month time.Month, day int, name string) {
+		days[monthDay{month, day}] = name
+	}
+
+	add(time.January, 1, "元日")
+	add(time.January, monday(year, time.January, 2), "成人の日")
+	add(time.February, 11, "建国記念の日")
+	if year >= 2020 {
+		add(time.February, 23, "天皇誕生日")
+	}
+	add(time.March, vernalEquinox(year), "春分の日")
+	add(time.April, 29, "昭和の日")
+	add(time.May, 3, "憲法記念日")
+	add(time.May, 4, "みどりの日")
+	add(time.May, 5, "こどもの日")
+	add(time.September, monday(year, time.September, 3), "敬老の日")
+	add(time.September, autumnalEquinox(year), "秋分の日")
+	add(time.November, 3, "文化の日")
+	add(time.November, 23, "勤労感謝の日")
+	if year <= 2018 {
+		add(time.December, 23, "天皇誕生日")
+	}
+
+	switch year {
+	case 2019:
+		add(time.May, 1, "休日（祝日扱い）")
+		add(time.October, 22, "休日（祝日扱い）")
+	case 2020: // moved for the Olympic Games
+		add(time.July, 23, "海の日")
+		add(time.July, 24, "スポーツの日")
+		add(time.August, 10, "山の日")
+	case 2021:
+		add(time.July, 22, "海の日")
+		add(time.July, 23, "スポーツの日")
+		add(time.August, 8, "山の日")
+	}
+	if year < 2020 || year > 2021 {
+		add(time.July, monday(year, time.July, 3), "海の日")
+		if year >= 2016 {
+			add(time.August, 11, "山の日")
+		}
+		if year >= 2020 {
+			add(time.October, monday(year, time.October, 2), "スポーツの日")
+		} else {
+			add(time.October, monday(year, time.October, 2), "体育の日")
+		}
+	}
+
+	// 国民の休日 between two holidays, then 振替休日 on the first day after a Sunday holiday that is not a holiday
+	for d := date(year, time.January, 2); d.Year() == year; d = d.AddDate(0, 0, 1) {
+		before, after := d.AddDate(0, 0, -1), d.AddDate(0, 0, 1)
+		_, holiday := days[key(d)]
+		_, holidayBefore := days[key(before)]
+		_, holidayAfter := days[key(after)]
+		if !holiday && holidayBefore && holidayAfter {
+			add(d.Month(), d.Day(), "休日")
+		}
+	}
+	for d := date(year, time.January, 1); d.Year() == year; d = d.AddDate(0, 0, 1) {
+		if _, holiday := days[key(d)]; !holiday || d.Weekday() != time.Sunday {
+			continue
+		}
+		substitute := d.AddDate(0, 0, 1)
+		for {
+			if _, holiday := days[key(substitute)]; !holiday {
+				break
+			}
+			substitute = substitute.AddDate(0, 0, 1)
+		}
+		if substitute.Year() == year {
+			add(substitute.Month(), substitute.Day(), "休日")
+		}
+	}
+	return days
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func key(date time.Time) monthDay {
+	return monthDay{date.Month(), date.Day()}
+}
+
+// monday returns the day of the nth Monday of a month, as in ハッピーマンデー holidays
+func monday(year int, month time.Month, n int) int {
+	first := date(year, month, 1).Weekday()
+	return 1 + (int(time.Monday-first)+7)%7 + 7*(n-1)
+}
+
+// vernalEquinox returns the day of 春分の日 in March, by the approximation used for the years 1980 to 2099
+func vernalEquinox(year int) int {
+	return int(20.8431+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+// autumnalEquinox returns the day of 秋分の日 in September, by the approximation used for the years 1980 to 2099
+func autumnalEquinox(year int) int {
+	return int(23.2488+0.242194*float64(year-1980)) - (year-1980)/4
+}
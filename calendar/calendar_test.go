@@ -0,0 +1,81 @@
+package calendar_test
+
+import (
+	"github.com/Kyash/zengin-go/calendar"
+	"github.com/Kyash/zengin-go/internal/zengintest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	japan := calendar.Japan()
+
+	if name, ok := japan.Holiday(time.Date(2019, 5, 1, 0, 0, 0, 0, jst)); !ok || name != "休日（祝日扱い）" {
+		t.Errorf("expected 2019/5/1 from the embedded list, got %q", name)
+	}
+	if name, ok := japan.Holiday(time.Date(2040, 9, 22, 0, 0, 0, 0, jst)); !ok || name != "秋分の日" {
+		t.Errorf("expected 2040/9/22 to be computed, got %q", name)
+	}
+	if actual := japan.NextBusinessDay(time.Date(2026, 12, 30, 0, 0, 0, 0, jst)); !actual.Equal(time.Date(2026, 12, 30, 0, 0, 0, 0, jst)) {
+		t.Errorf("expected 2026/12/30, got %v", actual)
+	}
+	if actual := japan.NextBusinessDay(time.Date(2026, 12, 31, 0, 0, 0, 0, jst)); !actual.Equal(time.Date(2027, 1, 4, 0, 0, 0, 0, jst)) {
+		t.Errorf("expected 2027/1/4, got %v", actual)
+	}
+	if actual := japan.AddBusinessDays(time.Date(2026, 5, 1, 0, 0, 0, 0, jst), 2); !actual.Equal(time.Date(2026, 5, 8, 0, 0, 0, 0, jst)) {
+		t.Errorf("expected 2026/5/8, got %v", actual)
+	}
+	if actual := japan.AddBusinessDays(time.Date(2026, 9, 24, 0, 0, 0, 0, jst), -1); !actual.Equal(time.Date(2026, 9, 18, 0, 0, 0, 0, jst)) {
+		t.Errorf("expected 2026/9/18, got %v", actual)
+	}
+
+	computed, err := calendar.Load(strings.NewReader("国民の祝日・休日月日,国民の祝日・休日名称\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for d := time.Date(2016, 1, 1, 0, 0, 0, 0, jst); d.Year() <= 2027; d = d.AddDate(0, 0, 1) {
+		expected, _ := japan.Holiday(d)
+		if actual, _ := computed.Holiday(d); actual != expected {
+			t.Errorf("%s: expected %q from the rules, got %q", d.Format(time.DateOnly), expected, actual)
+		}
+	}
+	for _, tt := range []struct {
+		year    int
+		covered bool
+	}{{2006, false}, {2007, true}, {2016, true}, {2027, true}, {2028, true}, {2099, true}, {2100, false}} {
+		if actual := japan.Covers(time.Date(tt.year, 9, 15, 0, 0, 0, 0, jst)); actual != tt.covered {
+			t.Errorf("Covers(%d): expected %v", tt.year, tt.covered)
+		}
+	}
+	if _, ok := japan.Holiday(time.Date(2003, 9, 15, 0, 0, 0, 0, jst)); ok {
+		t.Error("expected no holidays in 2003, which the calendar doesn't cover")
+	}
+
+	list := zengintest.ShiftJIS(t, "国民の祝日・休日月日,国民の祝日・休日名称\r\n2030/1/1,元日\r\n2003/9/15,敬老の日\r\n")
+	loaded, err := calendar.Load(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.IsBusinessDay(time.Date(2030, 1, 14, 0, 0, 0, 0, jst)) || loaded.IsBusinessDay(time.Date(2030, 1, 1, 0, 0, 0, 0, jst)) {
+		t.Error("expected the holidays of 2030 to be the loaded ones")
+	}
+	if !loaded.Covers(time.Date(2003, 1, 1, 0, 0, 0, 0, jst)) || loaded.IsBusinessDay(time.Date(2003, 9, 15, 0, 0, 0, 0, jst)) {
+		t.Error("expected 2003 to be covered by the loaded list")
+	}
+
+	for _, tt := range []struct {
+		date     time.Time
+		business bool
+	}{
+		{time.Date(2026, 9, 22, 0, 0, 0, 0, jst), false}, // 国民の休日
+		{time.Date(2026, 5, 6, 0, 0, 0, 0, jst), false},  // 振替休日
+		{time.Date(2026, 12, 31, 0, 0, 0, 0, jst), false},
+		{time.Date(2027, 1, 4, 0, 0, 0, 0, jst), true},
+	} {
+		if actual := japan.IsBusinessDay(tt.date); actual != tt.business {
+			t.Errorf("IsBusinessDay(%v): expected %v", tt.date, tt.business)
+		}
+	}
+}
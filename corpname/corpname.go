@@ -0,0 +1,176 @@
+// Package corpname splits Zengin names into the abbreviation of their legal entity type (法人略語) and their body,
+// so that "ｶ)ｷﾔﾂｼﾕ" and "ｷﾔﾂｼﾕ(ｶ" can be told to be the same company.
+package corpname
+
+import (
+	"github.com/Kyash/zengin-go/kana"
+	"sort"
+	"strings"
+)
+
+// Entity is a legal entity type with its abbreviation in Zengin names
+type Entity struct {
+	Abbreviation string // such as ｶ
+	Name         string // such as 株式会社
+}
+
+// Entities are the legal entity types with a standard abbreviation.
+// Types sharing an abbreviation are listed after the one Expand returns.
+var Entities = []Entity{
+	{"ｶ", "株式会社"},
+	{"ﾕ", "有限会社"},
+	{"ﾒ", "合名会社"},
+	{"ｼ", "合資会社"},
+	{"ﾄﾞ", "合同会社"},
+	{"ｿ", "相互会社"},
+	{"ｲ", "医療法人"},
+	{"ｲ", "医療法人社団"},
+	{"ｲ", "医療法人財団"},
+	{"ｲ", "社会医療法人"},
+	{"ｻﾞｲ", "財団法人"},
+	{"ｻﾞｲ", "一般財団法人"},
+	{"ｻﾞｲ", "公益財団法人"},
+	{"ｼﾔ", "社団法人"},
+	{"ｼﾔ", "一般社団法人"},
+	{"ｼﾔ", "公益社団法人"},
+	{"ｼﾕｳ", "宗教法人"},
+	{"ｶﾞｸ", "学校法人"},
+	{"ﾌｸ", "社会福祉法人"},
+	{"ﾎｺﾞ", "更生保護法人"},
+	{"ﾄｸﾋ", "特定非営利活動法人"},
+	{"ﾄﾞｸ", "独立行政法人"},
+	{"ﾁﾄﾞｸ", "地方独立行政法人"},
+	{"ﾀﾞｲ", "国立大学法人"},
+	{"ﾍﾞﾝ", "弁護士法人"},
+	{"ｾﾞｲ", "税理士法人"},
+	{"ｶﾝｻ", "監査法人"},
+	{"ｼﾎｳ", "司法書士法人"},
+	{"ﾕｳｸﾐ", "有限責任事業組合"},
+	{"ｾｲｷﾖｳ", "生活協同組合"},
+}
+
+// Position is where the entity type is written in a name
+type Position int
+
+const (
+	PositionNone   Position = iota // no entity type, such as the name of a person
+	PositionBefore                 // ｶ)ｷﾔﾂｼﾕ
+	PositionAfter                  // ｷﾔﾂｼﾕ(ｶ
+	PositionMiddle                 // ｷﾔﾂｼﾕ(ｶ)ﾄｳｷﾖｳｼﾃﾝ
+)
+
+// Name is a name split into its entity type and body
+type Name struct {
+	Entity   string   // abbreviation of the entity type, empty for PositionNone
+	Position Position // where the entity type is written
+	Body     string   // name without the entity type, the part before it for PositionMiddle
+	Rest     string   // part after the entity type for PositionMiddle, such as the name of an office
+}
+
+// Parse splits a name, written with an abbreviation such as "ｶ)ｷﾔﾂｼﾕ" or with the full entity type such as
+// "株式会社キャッシュ". The name is normalized by kana.Normalize, except for the entity types written in full.
+func Parse(name string) Name {
+	name = strings.TrimSpace(name)
+	for _, e := range longestNames() {
+		if body, ok := strings.CutPrefix(name, e.Name); ok {
+			return Name{Entity: e.Abbreviation, Position: PositionBefore, Body: normalize(body)}
+		}
+		if body, ok := strings.CutSuffix(name, e.Name); ok {
+			return Name{Entity: e.Abbreviation, Position: PositionAfter, Body: normalize(body)}
+		}
+	}
+
+	name = normalize(name)
+	for _, abbreviation := range abbreviations() {
+		if body, ok := strings.CutPrefix(name, abbreviation+")"); ok {
+			return Name{Entity: abbreviation, Position: PositionBefore, Body: strings.TrimSpace(body)}
+		}
+		if body, ok := strings.CutSuffix(name, "("+abbreviation); ok {
+			return Name{Entity: abbreviation, Position: PositionAfter, Body: strings.TrimSpace(body)}
+		}
+		if body, rest, ok := strings.Cut(name, "("+abbreviation+")"); ok {
+			return Name{Entity: abbreviation, Position: PositionMiddle, Body: strings.TrimSpace(body), Rest: strings.TrimSpace(rest)}
+		}
+	}
+	return Name{Body: name}
+}
+
+// Abbreviate returns the Zengin form of a full legal name, such as "ｶ)ｷﾔﾂｼﾕ" for "株式会社キャッシュ"
+func Abbreviate(legalName string) string {
+	return Parse(legalName).String()
+}
+
+// String returns the name with its entity type abbreviated at its position
+func (n Name) String() string {
+	switch n.Position {
+	case PositionBefore:
+		return n.Entity + ")" + n.Body
+	case PositionAfter:
+		return n.Body + "(" + n.Entity
+	case PositionMiddle:
+		return n.Body + "(" + n.Entity + ")" + n.Rest
+	default:
+		return n.Body
+	}
+}
+
+// Expand returns the name with its entity type written in full, such as "株式会社ｷﾔﾂｼﾕ"
+func (n Name) Expand() string {
+	entity := EntityName(n.Entity)
+	switch n.Position {
+	case PositionBefore:
+		return entity + n.Body
+	case PositionAfter:
+		return n.Body + entity
+	case PositionMiddle:
+		return n.Body + entity + n.Rest
+	default:
+		return n.Body
+	}
+}
+
+// Same reports whether two names are of the same entity, wherever the entity type is written
+func Same(a, b string) bool {
+	x, y := Parse(a), Parse(b)
+	return x.Entity == y.Entity && x.Body == y.Body && x.Rest == y.Rest
+}
+
+// EntityName returns the entity type of an abbreviation, such as 株式会社 for ｶ, or an empty string if it is unknown
+func EntityName(abbreviation string) string {
+	for _, e := range Entities {
+		if e.Abbreviation == abbreviation {
+			return e.Name
+		}
+	}
+	return ""
+}
+
+// abbreviations returns the distinct abbreviations, longest first so that ｼﾕｳ is not taken for ｼ
+func abbreviations() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, e := range Entities {
+		if !seen[e.Abbreviation] {
+			seen[e.Abbreviation] = true
+			result = append(result, e.Abbreviation)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return len([]rune(result[i])) > len([]rune(result[j]))
+	})
+	return result
+}
+
+// longestNames returns the entity types longest first, so that 医療法人社団 is not taken for 医療法人
+func longestNames() []Entity {
+	result := append([]Entity(nil), Entities...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return len([]rune(result[i].Name)) > len([]rune(result[j].Name))
+	})
+	return result
+}
+
+// normalize converts a name or body with kana.Normalize and removes the spaces around it
+func normalize(s string) string {
+	return strings.TrimSpace(kana.Normalize(s))
+}
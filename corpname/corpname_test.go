@@ -0,0 +1,35 @@
+package corpname_test
+
+import (
+	"github.com/Kyash/zengin-go/corpname"
+	"testing"
+)
+
+func TestCorporateNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected corpname.Name
+	}{
+		{"ｶ)ｷﾔﾂｼﾕ", corpname.Name{Entity: "ｶ", Position: corpname.PositionBefore, Body: "ｷﾔﾂｼﾕ"}},
+		{"ｷﾔﾂｼﾕ(ｶ", corpname.Name{Entity: "ｶ", Position: corpname.PositionAfter, Body: "ｷﾔﾂｼﾕ"}},
+		{"ｷﾔﾂｼﾕ(ｶ)ﾄｳｷﾖｳｼﾃﾝ", corpname.Name{Entity: "ｶ", Position: corpname.PositionMiddle, Body: "ｷﾔﾂｼﾕ", Rest: "ﾄｳｷﾖｳｼﾃﾝ"}},
+		{"ｼﾕｳ)ｹﾝｼﾝｼﾞ", corpname.Name{Entity: "ｼﾕｳ", Position: corpname.PositionBefore, Body: "ｹﾝｼﾝｼﾞ"}},
+		{"医療法人社団ケンシンカイ", corpname.Name{Entity: "ｲ", Position: corpname.PositionBefore, Body: "ｹﾝｼﾝｶｲ"}},
+		{"ｹﾝｼﾝ ﾀﾛｳ", corpname.Name{Body: "ｹﾝｼﾝ ﾀﾛｳ"}},
+	}
+	for _, tt := range tests {
+		if actual := corpname.Parse(tt.name); actual != tt.expected {
+			t.Errorf("Parse(%q): expected %+v, got %+v", tt.name, tt.expected, actual)
+		}
+	}
+
+	if !corpname.Same("ｶ)ｷﾔﾂｼﾕ", "ｷﾔﾂｼﾕ(ｶ") || corpname.Same("ｶ)ｷﾔﾂｼﾕ", "ﾕ)ｷﾔﾂｼﾕ") {
+		t.Error("expected names to be compared by entity type and body")
+	}
+	if actual := corpname.Abbreviate("キャッシュ株式会社"); actual != "ｷﾔﾂｼﾕ(ｶ" {
+		t.Errorf("expected ｷﾔﾂｼﾕ(ｶ, got %q", actual)
+	}
+	if actual := corpname.Parse("ﾕ)ｹﾝｼﾝ").Expand(); actual != "有限会社ｹﾝｼﾝ" {
+		t.Errorf("expected 有限会社ｹﾝｼﾝ, got %q", actual)
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/Kyash/zengin-go
 
-go 1.20
+go 1.21
 
 require (
 	golang.org/x/net v0.19.0
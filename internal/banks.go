@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"strings"
+)
+
+// checkBank looks up a bank and branch code in the bank directory of the config, if any.
+// It returns a *types.ParseError for an unknown code, or keeps a warning for an unknown bank code
+// of a types.PartialDirectory. It fills in blank names and keeps a warning for a name that doesn't match the directory.
+func (p *reporter) checkBank(line record, bankCodeField, bankNameField, branchCodeField, branchNameField field,
+	bankCode, branchCode string, bankName, branchName *string) error {
+	directory := p.config.BankDirectory
+	if directory == nil {
+		return nil
+	}
+
+	bank, ok := directory.Bank(bankCode)
+	if !ok {
+		err := bankCodeField.error(bankCode, errors.New("unknown bank code"))
+		if partial, ok := directory.(types.PartialDirectory); ok && partial.Partial() {
+			p.warn(err)
+			return nil
+		}
+		return err
+	}
+	p.checkName(bankNameField, bankName, bank.Kana)
+
+	if bank.BranchCount == 0 {
+		return nil // branches of the bank are not listed
+	}
+	branch, ok := directory.Branch(bankCode, branchCode)
+	if !ok {
+		return branchCodeField.error(branchCode, fmt.Errorf("unknown branch code of bank %s", bankCode))
+	}
+	p.checkName(branchNameField, branchName, branch.Kana)
+	return nil
+}
+
+// checkName fills in a blank name with the name of the directory, or keeps a warning if they don't match.
+// Names match if one is the other cut short, as names longer than the field are cut or abbreviated.
+func (p *reporter) checkName(f field, name *string, kana string) {
+	if kana == "" {
+		return
+	}
+	width := f.end - f.start
+	expected := []rune(kana)
+	if len(expected) > width {
+		expected = expected[:width]
+	}
+	trimmed := strings.TrimRight(*name, " ")
+	switch {
+	case trimmed == "":
+		*name = string(expected)
+	case strings.HasPrefix(string(expected), trimmed), strings.HasPrefix(trimmed, string(expected)):
+	default:
+		p.warn(f.error(*name, fmt.Errorf("name doesn't match the bank directory: %s", kana)))
+	}
+}
+
+// warn keeps a warning about the current record
+func (p *reporter) warn(warning *types.ParseError) {
+	warning.Record = p.records.record
+	p.config.warn("bank directory mismatch", "warning", warning)
+	p.warnings = append(p.warnings, warning)
+}
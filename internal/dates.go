@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"time"
+)
+
+// checkCalendar returns types.ErrNoReferenceDate if the config has a calendar without a clock
+func checkCalendar(config Config) error {
+	if config.Calendar != nil && config.Clock == nil {
+		return fmt.Errorf("calendar without a clock: %w", types.ErrNoReferenceDate)
+	}
+	return nil
+}
+
+// checkTransferDate resolves the transfer date of a header if the config has a clock,
+// and returns a *types.ParseError for a date that can't be resolved or on which banks are closed
+func (p *reporter) checkTransferDate(line record, header *types.Header) error {
+	if p.config.Clock == nil {
+		return nil
+	}
+
+	date, err := header.ResolveTransferDate(p.config.Clock())
+	if err != nil {
+		return headerTransferDate.error(header.TransferDate, fmt.Errorf("invalid date: %w", err))
+	}
+	if p.config.Calendar != nil {
+		if err := checkBusinessDay(p.config.Calendar, date); err != nil {
+			return headerTransferDate.error(header.TransferDate, err)
+		}
+	}
+	header.ResolvedTransferDate = date
+	return nil
+}
+
+// checkTransferDate fills in a blank transfer date from the resolved one and returns an error for a date
+// that doesn't match it, or that isn't resolved or on which banks are closed if the encoder has a calendar
+func (e *Encoder) checkTransferDate(header *types.Header) error {
+	resolved := header.ResolvedTransferDate
+	if !resolved.IsZero() {
+		switch date := resolved.Format("0102"); header.TransferDate {
+		case "":
+			header.TransferDate = date
+		case date:
+		default:
+			return fmt.Errorf("transfer date %s doesn't match the resolved transfer date %s", header.TransferDate, resolved.Format(time.DateOnly))
+		}
+	}
+	if e.calendar == nil {
+		return nil
+	}
+
+	if resolved.IsZero() {
+		return fmt.Errorf("transfer date %s: %w", header.TransferDate, types.ErrNoReferenceDate)
+	}
+	if err := checkBusinessDay(e.calendar, resolved); err != nil {
+		return fmt.Errorf("transfer date %w", err)
+	}
+	return nil
+}
+
+// checkBusinessDay returns an error wrapping types.ErrDateNotCovered for a date outside the years of
+// a types.LimitedCalendar, or types.ErrNotBusinessDay for a date on which banks are closed
+func checkBusinessDay(calendar types.Calendar, date time.Time) error {
+	if limited, ok := calendar.(types.LimitedCalendar); ok && !limited.Covers(date) {
+		return fmt.Errorf("%s: %w", date.Format(time.DateOnly), types.ErrDateNotCovered)
+	}
+	if !calendar.IsBusinessDay(date) {
+		return fmt.Errorf("%s: %w", date.Format(time.DateOnly), types.ErrNotBusinessDay)
+	}
+	return nil
+}
@@ -0,0 +1,450 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"strconv"
+)
+
+// debitLayout is the layout of 口座振替 files
+var debitLayout = fileLayout[types.DebitHeader, types.DebitData, types.DebitTrailer]{
+	length:  types.RecordLength,
+	header:  parseDebitHeader,
+	data:    parseDebitData,
+	trailer: parseDebitTrailer,
+	check:   checkDebitTrailerRecord,
+}
+
+// ParseDebitWithConfig parses a 口座振替 file, either a request file or a result file returned by the bank
+func ParseDebitWithConfig(file Reader, config Config) (types.DebitResult, error) {
+	var result types.DebitResult
+	encoding, diagnostics, err := parseFile(file, config, debitLayout,
+		func(header types.DebitHeader, data []types.DebitData, trailer types.DebitTrailer) {
+			result.Groups = append(result.Groups, types.DebitGroup{Header: header, Data: data, Trailer: trailer})
+		})
+	if err != nil {
+		return types.DebitResult{}, err
+	}
+	result.Diagnostics = diagnostics
+	result.Encoding = encoding
+
+	return result, nil
+}
+
+// debitTotals returns the totals of the data records: all of them, the transferred ones and the failed ones.
+// Records with a blank result code are only counted in the total.
+func debitTotals(data []types.DebitData) (types.DebitTrailer, error) {
+	var totals types.DebitTrailer
+	for _, block := range data {
+		totals.TotalCount++
+		totals.TotalAmount += block.Amount
+		switch block.ResultCode {
+		case types.ResultCodeBlank:
+		case types.ResultCodeTransferred:
+			totals.TransferredCount++
+			totals.TransferredAmount += block.Amount
+		case types.ResultCodeUndefined:
+			return types.DebitTrailer{}, errors.New("undefined result code")
+		default:
+			totals.FailedCount++
+			totals.FailedAmount += block.Amount
+		}
+	}
+	return totals, nil
+}
+
+// isResultTrailer reports whether the trailer is from a result file: request files leave the result totals at zero
+func isResultTrailer(trailer types.DebitTrailer) bool {
+	return trailer.TransferredCount != 0 || trailer.FailedCount != 0
+}
+
+// checkDebitTrailerRecord returns a *types.ParseError on the trailer field that doesn't match the data records.
+// The result totals are only checked in result files.
+func checkDebitTrailerRecord(line record, _ types.DebitHeader, data []types.DebitData, trailer types.DebitTrailer) error {
+	totals, err := debitTotals(data)
+	if err != nil {
+		return err
+	}
+	checks := []struct {
+		f        field
+		expected uint64
+		actual   uint64
+		result   bool
+	}{
+		{debitTrailerTotalCount, uint64(totals.TotalCount), uint64(trailer.TotalCount), false},
+		{debitTrailerTotalAmount, totals.TotalAmount, trailer.TotalAmount, false},
+		{debitTrailerTransferredCount, uint64(totals.TransferredCount), uint64(trailer.TransferredCount), true},
+		{debitTrailerTransferredAmount, totals.TransferredAmount, trailer.TransferredAmount, true},
+		{debitTrailerFailedCount, uint64(totals.FailedCount), uint64(trailer.FailedCount), true},
+		{debitTrailerFailedAmount, totals.FailedAmount, trailer.FailedAmount, true},
+	}
+	for _, c := range checks {
+		if c.result && !isResultTrailer(trailer) {
+			continue
+		}
+		if c.expected != c.actual {
+			return c.f.error(c.f.value(line), fmt.Errorf("%s mismatch: data records sum up to %d", c.f.nameJa, c.expected))
+		}
+	}
+	return nil
+}
+
+func parseDebitHeader(line record, encoding types.Encoding) (types.DebitHeader, error) {
+	if line.len() < types.MinHeaderLength {
+		return types.DebitHeader{}, recordError(line, errors.New("header line too short"))
+	}
+
+	header := types.DebitHeader{}
+
+	recordType, err := parseRecordType(line, debitHeaderRecordType)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.RecordType = recordType
+
+	categoryCode, err := parseCategoryCode(line, debitHeaderCategoryCode)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	if categoryCode != types.CategoryCodeDebit {
+		value := debitHeaderCategoryCode.value(line)
+		return types.DebitHeader{}, debitHeaderCategoryCode.error(value, errors.New("not a 口座振替 file: "+value))
+	}
+	header.CategoryCode = categoryCode
+
+	encodingType, err := parseEncodingType(line, debitHeaderEncodingType, encoding)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.EncodingType = encodingType
+
+	consignorCode, err := parseConsignorCode(line, debitHeaderConsignorCode)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.ConsignorCode = consignorCode
+
+	consignorName, err := parseText(line, debitHeaderConsignorName)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.ConsignorName = consignorName
+
+	date, err := parseDate(line, debitHeaderDebitDate)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.DebitDate = date
+
+	bankCode, err := parseBankCode(line, debitHeaderBankCode)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.BankCode = bankCode
+
+	bankName, err := parseText(line, debitHeaderBankName)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.BankName = bankName
+
+	branchCode, err := parseBranchCode(line, debitHeaderBranchCode)
+	if err != nil {
+		return types.DebitHeader{}, err
+	}
+	header.BranchCode = branchCode
+
+	// Fields below are optional
+
+	if debitHeaderBranchName.in(line) {
+		branchName, err := parseText(line, debitHeaderBranchName)
+		if err != nil {
+			return types.DebitHeader{}, err
+		}
+		header.BranchName = branchName
+	}
+
+	if debitHeaderAccountType.in(line) {
+		accountType, err := parseAccountType(line, debitHeaderAccountType)
+		if err != nil {
+			return types.DebitHeader{}, err
+		}
+		header.AccountType = accountType
+	}
+
+	if debitHeaderAccountNumber.in(line) {
+		accountNumber, err := parseAccountNumber(line, debitHeaderAccountNumber)
+		if err != nil {
+			return types.DebitHeader{}, err
+		}
+		header.AccountNumber = accountNumber
+	}
+
+	if debitHeaderDummy.in(line) {
+		header.Dummy = debitHeaderDummy.value(line)
+	}
+
+	return header, nil
+}
+
+func parseDebitData(line record) (types.DebitData, error) {
+	if line.len() < types.MinDataLength {
+		return types.DebitData{}, recordError(line, errors.New("data line is too short"))
+	}
+
+	data := types.DebitData{}
+
+	recordType, err := parseRecordType(line, debitDataRecordType)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.RecordType = recordType
+
+	bankCode, err := parseBankCode(line, debitDataBankCode)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.BankCode = bankCode
+
+	bankName, err := parseText(line, debitDataBankName) // optional
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.BankName = bankName
+
+	branchCode, err := parseBranchCode(line, debitDataBranchCode)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.BranchCode = branchCode
+
+	branchName, err := parseText(line, debitDataBranchName) // optional
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.BranchName = branchName
+
+	data.Dummy1 = debitDataDummy1.value(line)
+
+	accountType, err := parseAccountType(line, debitDataAccountType)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.AccountType = accountType
+
+	accountNumber, err := parseAccountNumber(line, debitDataAccountNumber)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.AccountNumber = accountNumber
+
+	accountName, err := parseText(line, debitDataAccountName)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.AccountName = accountName
+
+	amount, err := parseAmount(line, debitDataAmount)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.Amount = amount
+
+	newCode, err := parseNewCode(line, debitDataNewCode)
+	if err != nil {
+		return types.DebitData{}, err
+	}
+	data.NewCode = newCode
+
+	// Fields below are optional
+
+	if debitDataCustomerNumber.in(line) {
+		customerNumber, err := parseText(line, debitDataCustomerNumber)
+		if err != nil {
+			return types.DebitData{}, err
+		}
+		data.CustomerNumber = customerNumber
+	}
+
+	if debitDataResultCode.in(line) {
+		resultCode, err := parseResultCode(line, debitDataResultCode)
+		if err != nil {
+			return types.DebitData{}, err
+		}
+		data.ResultCode = resultCode
+	}
+
+	if debitDataDummy2.in(line) {
+		data.Dummy2 = debitDataDummy2.value(line)
+	}
+
+	return data, nil
+}
+
+func parseDebitTrailer(line record) (types.DebitTrailer, error) {
+	if line.len() < debitTrailerFailedAmount.end {
+		return types.DebitTrailer{}, recordError(line, errors.New("trailer line too short"))
+	}
+
+	trailer := types.DebitTrailer{}
+
+	recordType, err := parseRecordType(line, debitTrailerRecordType)
+	if err != nil {
+		return types.DebitTrailer{}, err
+	}
+	trailer.RecordType = recordType
+
+	counts := []struct {
+		f     field
+		count *int
+	}{
+		{debitTrailerTotalCount, &trailer.TotalCount},
+		{debitTrailerTransferredCount, &trailer.TransferredCount},
+		{debitTrailerFailedCount, &trailer.FailedCount},
+	}
+	for _, c := range counts {
+		if *c.count, err = parseCount(line, c.f); err != nil {
+			return types.DebitTrailer{}, err
+		}
+	}
+
+	amounts := []struct {
+		f      field
+		amount *uint64
+	}{
+		{debitTrailerTotalAmount, &trailer.TotalAmount},
+		{debitTrailerTransferredAmount, &trailer.TransferredAmount},
+		{debitTrailerFailedAmount, &trailer.FailedAmount},
+	}
+	for _, a := range amounts {
+		if *a.amount, err = parseAmount(line, a.f); err != nil {
+			return types.DebitTrailer{}, err
+		}
+	}
+
+	if debitTrailerDummy.in(line) {
+		trailer.Dummy = debitTrailerDummy.value(line)
+	}
+
+	return trailer, nil
+}
+
+// EncodeDebit writes a header record of a 口座振替 file, its data records and a trailer record.
+// If trailer is nil the total count and amount are computed from the data records and the result totals are left
+// at zero, as in a request file. Otherwise its totals must match the data records.
+func (e *Encoder) EncodeDebit(header types.DebitHeader, data []types.DebitData, trailer *types.DebitTrailer) error {
+	if trailer == nil {
+		totals, err := debitTotals(data)
+		if err != nil {
+			return err
+		}
+		trailer = &types.DebitTrailer{TotalCount: totals.TotalCount, TotalAmount: totals.TotalAmount}
+	} else if err := checkDebitTrailer(data, *trailer); err != nil {
+		return err
+	}
+	if e.sanitize {
+		data = append([]types.DebitData(nil), data...)
+		sanitize(&header.ConsignorName, &header.BankName, &header.BranchName)
+		for i := range data {
+			sanitize(&data[i].BankName, &data[i].BranchName, &data[i].AccountName, &data[i].CustomerNumber)
+		}
+	}
+
+	var records []string
+	record, err := formatDebitHeader(header, e.encoding)
+	if err != nil {
+		return fmt.Errorf("error formatting header: %w", err)
+	}
+	records = append(records, record)
+	for i, block := range data {
+		record, err := formatDebitData(block)
+		if err != nil {
+			return fmt.Errorf("error formatting data record %d: %w", i+1, err)
+		}
+		records = append(records, record)
+	}
+	record, err = formatDebitTrailer(*trailer)
+	if err != nil {
+		return fmt.Errorf("error formatting trailer record: %w", err)
+	}
+	records = append(records, record)
+
+	return e.write(records)
+}
+
+// checkDebitTrailer returns an error if the trailer totals do not match the data records
+func checkDebitTrailer(data []types.DebitData, trailer types.DebitTrailer) error {
+	totals, err := debitTotals(data)
+	if err != nil {
+		return err
+	}
+	if !isResultTrailer(trailer) {
+		totals.TransferredCount, totals.TransferredAmount = 0, 0
+		totals.FailedCount, totals.FailedAmount = 0, 0
+	}
+	totals.RecordType, totals.Dummy = trailer.RecordType, trailer.Dummy
+	if totals != trailer {
+		return fmt.Errorf("trailer totals mismatch: data records sum up to %+v", totals)
+	}
+	return nil
+}
+
+func formatDebitHeader(header types.DebitHeader, encoding types.Encoding) (string, error) {
+	var b recordBuilder
+	b.text(debitHeaderRecordType, "1")
+	b.text(debitHeaderCategoryCode, "91")
+	b.code(debitHeaderEncodingType, defaultEncodingType(header.EncodingType, encoding))
+	b.code(debitHeaderConsignorCode, header.ConsignorCode)
+	b.name(debitHeaderConsignorName, header.ConsignorName)
+	b.date(debitHeaderDebitDate, header.DebitDate)
+	b.code(debitHeaderBankCode, header.BankCode)
+	b.name(debitHeaderBankName, header.BankName)
+	b.code(debitHeaderBranchCode, header.BranchCode)
+	b.name(debitHeaderBranchName, header.BranchName)
+	b.accountType(debitHeaderAccountType, header.AccountType)
+	b.code(debitHeaderAccountNumber, header.AccountNumber)
+	b.text(debitHeaderDummy, header.Dummy)
+	return b.String(), b.err
+}
+
+func formatDebitData(data types.DebitData) (string, error) {
+	var b recordBuilder
+	b.text(debitDataRecordType, "2")
+	b.code(debitDataBankCode, data.BankCode)
+	b.name(debitDataBankName, data.BankName)
+	b.code(debitDataBranchCode, data.BranchCode)
+	b.name(debitDataBranchName, data.BranchName)
+	b.text(debitDataDummy1, data.Dummy1)
+	b.accountType(debitDataAccountType, data.AccountType)
+	b.code(debitDataAccountNumber, data.AccountNumber)
+	b.name(debitDataAccountName, data.AccountName)
+	b.number(debitDataAmount, data.Amount)
+	b.newCode(debitDataNewCode, data.NewCode)
+	b.name(debitDataCustomerNumber, data.CustomerNumber)
+	b.resultCode(debitDataResultCode, data.ResultCode)
+	b.text(debitDataDummy2, data.Dummy2)
+	return b.String(), b.err
+}
+
+func formatDebitTrailer(trailer types.DebitTrailer) (string, error) {
+	var b recordBuilder
+	b.text(debitTrailerRecordType, "8")
+	b.number(debitTrailerTotalCount, uint64(trailer.TotalCount))
+	b.number(debitTrailerTotalAmount, trailer.TotalAmount)
+	b.number(debitTrailerTransferredCount, uint64(trailer.TransferredCount))
+	b.number(debitTrailerTransferredAmount, trailer.TransferredAmount)
+	b.number(debitTrailerFailedCount, uint64(trailer.FailedCount))
+	b.number(debitTrailerFailedAmount, trailer.FailedAmount)
+	b.text(debitTrailerDummy, trailer.Dummy)
+	return b.String(), b.err
+}
+
+func (b *recordBuilder) resultCode(f field, resultCode types.ResultCode) {
+	code := resultCode.Code()
+	if code == "" {
+		b.setErr(f.error(strconv.Itoa(int(resultCode)), errors.New("invalid result code")))
+		return
+	}
+	b.text(f, code)
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"github.com/Kyash/zengin-go/types"
+)
+
+// Decoder reads transfers one at a time, keeping only the current header and running totals in memory.
+type Decoder struct {
+	records *recordScanner
+	header  types.Header
+	group   int
+	count   int
+	amount  uint64
+	err     error
+}
+
+func NewDecoder(file Reader) (*Decoder, error) {
+	records, err := newRecordScanner(file, Config{}, types.RecordLength)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{records: records}, nil
+}
+
+// Next returns the next transfer, or io.EOF after the end record.
+// Transfers are returned before their trailer is read, so their Trailer is always empty.
+// When a trailer doesn't match the data records of its group, Next returns a *types.GroupError
+// and decoding can go on with the next group. Any other error is returned by every later call.
+func (d *Decoder) Next() (types.Transfer, error) {
+	for d.err == nil {
+		state, line, err := d.records.next()
+		if err != nil {
+			d.err = err
+			break
+		}
+
+		switch state {
+		case StateHeader:
+			header, err := parseHeader(line, d.records.encoding)
+			if err != nil {
+				d.err = d.records.wrap(err)
+				break
+			}
+			d.header = header
+			d.group++
+			d.count = 0
+			d.amount = 0
+
+		case StateData:
+			data, err := parseData(line, d.header.CategoryCode)
+			if err != nil {
+				d.err = d.records.wrap(err)
+				break
+			}
+			d.count++
+			d.amount += data.Amount
+			return createTransfer(d.header, data), nil
+
+		case StateTrailer:
+			trailer, err := parseTrailer(line)
+			if err != nil {
+				d.err = d.records.wrap(err)
+				break
+			}
+			if err := checkTrailerRecord(line, d.count, d.amount, trailer); err != nil {
+				return types.Transfer{}, &types.GroupError{
+					Group:   d.group,
+					Header:  d.header,
+					Trailer: trailer,
+					Count:   d.count,
+					Amount:  d.amount,
+					Err:     d.records.wrap(err),
+				}
+			}
+		}
+	}
+	return types.Transfer{}, d.err
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"sync"
+)
+
+var (
+	encodingsMutex sync.RWMutex
+	encodings      = map[types.Encoding]encoding.Encoding{
+		types.EncodingShiftJIS: japanese.ShiftJIS,
+		types.EncodingUTF8:     unicode.UTF8,
+	}
+)
+
+// RegisterEncoding makes a character encoding, such as an EBCDIC code page, available to the parser
+// and the encoder, and returns the identifier to select it with.
+// Registered encodings must be single-byte for records to stay types.RecordLength bytes long.
+// Files with line breaks are split before decoding on the line feed of the encoding, and on its NL
+// for EBCDIC code pages. Stateful encodings such as ISO-2022-JP are not supported: the JIS コード区分
+// of Zengin files is the 8-bit JIS X 0201 code, whose characters types.EncodingShiftJIS reads as they are.
+func RegisterEncoding(e encoding.Encoding) types.Encoding {
+	encodingsMutex.Lock()
+	defer encodingsMutex.Unlock()
+
+	id := types.Encoding(len(encodings) + 1)
+	encodings[id] = e
+	return id
+}
+
+func lookupEncoding(id types.Encoding) (encoding.Encoding, error) {
+	encodingsMutex.RLock()
+	defer encodingsMutex.RUnlock()
+
+	e, ok := encodings[id]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %d", id)
+	}
+	return e, nil
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"github.com/Kyash/zengin-go/types"
+	"io"
+)
+
+// fileLayout parses the records of a file format other than 総合振込
+type fileLayout[H, D, T any] struct {
+	length  int // length of the fixed-length records
+	header  func(line record, encoding types.Encoding) (H, error)
+	data    func(line record) (D, error)
+	trailer func(line record) (T, error)
+	check   func(line record, header H, data []D, trailer T) error // checks the trailer totals
+}
+
+// parseFile parses a file with the given layout and calls group for every header group with a valid header
+// and trailer record. In lenient mode invalid data records are left out of the group.
+// It returns the encoding of the file and the errors found in lenient mode.
+func parseFile[H, D, T any](file Reader, config Config, layout fileLayout[H, D, T],
+	group func(header H, data []D, trailer T)) (types.Encoding, []*types.ParseError, error) {
+	records, err := newRecordScanner(file, config, layout.length)
+	if err != nil {
+		return types.EncodingUndefined, nil, err
+	}
+	p := &reporter{records: records, config: config}
+
+	var header H
+	var data []D
+	valid := false // whether the header record of the current group was parsed
+	for {
+		state, line, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err := p.report(err); err != nil {
+			return types.EncodingUndefined, nil, err
+		}
+
+		switch state {
+		case StateHeader:
+			h, err := layout.header(line, records.encoding)
+			if err := p.report(err); err != nil {
+				return types.EncodingUndefined, nil, err
+			}
+			header = h
+			data = nil
+			valid = err == nil
+
+		case StateData:
+			d, err := layout.data(line)
+			if err := p.report(err); err != nil {
+				return types.EncodingUndefined, nil, err
+			}
+			if err != nil {
+				continue // left out of the group, the trailer check reports the totals that don't match
+			}
+			data = append(data, d)
+
+		case StateTrailer:
+			trailer, err := layout.trailer(line)
+			if err := p.report(err); err != nil {
+				return types.EncodingUndefined, nil, err
+			}
+			if !valid || err != nil {
+				valid = false
+				continue
+			}
+			valid = false
+			if err := p.report(layout.check(line, header, data, trailer)); err != nil {
+				return types.EncodingUndefined, nil, err
+			}
+			group(header, data, trailer)
+		}
+	}
+
+	return records.encoding, p.diagnostics, nil
+}
@@ -2,82 +2,220 @@ package internal
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
 	"github.com/Kyash/zengin-go/types"
 	"golang.org/x/net/html/charset"
-	"golang.org/x/text/encoding/japanese"
-	"golang.org/x/text/transform"
+	"golang.org/x/text/encoding"
 	"io"
-	"log"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type Reader interface {
 	io.Reader
 }
 
-func guessEncoding(file Reader) (*bufio.Scanner, types.Encoding, error) {
-	var encoding types.Encoding
-	reader := bufio.NewReader(file)
-	peekBytes, err := reader.Peek(1024)
-	if err != nil && err != io.EOF {
-		log.Fatal("couldn't read from file", "error", err)
-		return nil, types.EncodingUndefined, err
-	}
-
+// guessEncoding detects the encoding of a file from its first bytes
+func guessEncoding(peekBytes []byte) (types.Encoding, string) {
 	// Ignore "certain" (3rd value), as during testing it was always false, even though it correctly detects utf-8.
-	_, name, _ := charset.DetermineEncoding(peekBytes, "")
+	_, name, _ := charset.DetermineEncoding(trimIncompleteRune(peekBytes), "")
 
-	var scanner *bufio.Scanner
 	switch name {
 	case "utf-8":
-		encoding = types.EncodingUTF8
-		scanner = bufio.NewScanner(reader)
+		return types.EncodingUTF8, name
 	// Shift-JIS can't be reliably detected, so we'll assume it's Shift-JIS if it's not UTF-8.
 	default:
-		encoding = types.EncodingShiftJIS
-		scanner = bufio.NewScanner(transform.NewReader(reader, japanese.ShiftJIS.NewDecoder()))
+		return types.EncodingShiftJIS, name
 	}
+}
 
-	return scanner, encoding, nil
+// guessRecordFormat detects fixed-length records from the first bytes of a file:
+// a file with line breaks has one within its first record.
+func guessRecordFormat(peekBytes []byte, length int, breaks []byte) types.RecordFormat {
+	if len(peekBytes) > length && indexAnyByte(peekBytes, breaks) < 0 {
+		return types.RecordFormatFixed
+	}
+	return types.RecordFormatLines
 }
 
-func parseCategoryCode(categoryCode string) (types.CategoryCode, error) {
-	switch categoryCode {
-	case "21":
-		return types.CategoryCodeCombination, nil
-	case "11", "71":
-		return types.CategoryCodePayment, nil
-	case "12", "72":
-		return types.CategoryCodeBonus, nil
-	default:
-		return types.CategoryCodeUndefined, errors.New("unknown category code: " + categoryCode)
+// lineBreaks returns the bytes that end a line in an encoding, nil for UTF-8: the line feed,
+// and the NL of EBCDIC code pages, as long as they are single bytes. Files are split on them before decoding.
+// It also returns the bytes of the line endings to trim from a record, which add the carriage return.
+func lineBreaks(e encoding.Encoding) (breaks []byte, endings []byte) {
+	breaks, endings = []byte{'\n'}, []byte{'\r', '\n'}
+	if e == nil {
+		return breaks, endings
+	}
+	breaks, endings = nil, nil
+	for _, s := range []string{"\n", "\u0085", "\r"} {
+		b, err := e.NewEncoder().Bytes([]byte(s))
+		if err != nil || len(b) != 1 || bytes.IndexByte(endings, b[0]) >= 0 {
+			continue
+		}
+		if s != "\r" {
+			breaks = append(breaks, b[0])
+		}
+		endings = append(endings, b[0])
+	}
+	return breaks, endings
+}
+
+// indexAnyByte returns the index of the first byte of b that is one of chars, or -1
+func indexAnyByte(b []byte, chars []byte) int {
+	for i, c := range b {
+		if bytes.IndexByte(chars, c) >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// trimIncompleteRune drops a multi-byte UTF-8 character cut at the end of b, so that it doesn't make b invalid UTF-8
+func trimIncompleteRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
 	}
+	return b
 }
 
-func parseSenderCode(senderCode string) (string, error) {
+// scanRecords returns a bufio.SplitFunc like bufio.ScanLines for lines ending with one of breaks,
+// except that it keeps the line ending so that the line ending policy can be checked
+func scanRecords(breaks []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := indexAnyByte(data, breaks); i >= 0 {
+			return i + 1, data[0 : i+1], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// scanFixedBytes returns a bufio.SplitFunc for records of length bytes without line breaks
+func scanFixedBytes(length int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) >= length {
+			return length, data[0:length], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// scanFixedRunes returns a bufio.SplitFunc for UTF-8 records of length characters without line breaks
+func scanFixedRunes(length int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		size := 0
+		for i := 0; i < length; i++ {
+			if !utf8.FullRune(data[size:]) {
+				if atEOF && len(data) > 0 {
+					return len(data), data, nil
+				}
+				return 0, nil, nil
+			}
+			_, n := utf8.DecodeRune(data[size:])
+			size += n
+		}
+		return size, data[0:size], nil
+	}
+}
+
+func parseRecordType(line record, f field) (string, error) {
+	recordType := f.value(line)
+	if recordType != f.recordType {
+		return "", f.error(recordType, errors.New("record type is not "+f.recordType))
+	}
+	return recordType, nil
+}
+
+// parseText parses a name or other free text field, which must only use the Zengin character set
+func parseText(line record, f field) (string, error) {
+	text := f.value(line)
+	if err := kana.Validate(text); err != nil {
+		return "", f.error(text, err)
+	}
+	return text, nil
+}
+
+func parseCategoryCode(line record, f field) (types.CategoryCode, error) {
+	categoryCode := f.value(line)
+	code := types.ParseCategoryCode(categoryCode)
+	if code == types.CategoryCodeUndefined {
+		return code, f.error(categoryCode, errors.New("unknown category code: "+categoryCode))
+	}
+	return code, nil
+}
+
+// parseEncodingType parses コード区分, where "0" (JIS) is only valid for files in Shift-JIS
+func parseEncodingType(line record, f field, encoding types.Encoding) (string, error) {
+	encodingType := f.value(line)
+	if encoding != types.EncodingShiftJIS && encodingType == "0" {
+		return "", f.error(encodingType, errors.New("unsupported encoding type: "+encodingType))
+	}
+	return encodingType, nil
+}
+
+func parseSenderCode(line record, f field) (string, error) {
+	senderCode := f.value(line)
 	if len(senderCode) != 10 {
-		return "", errors.New("sender code must be 10 digits")
+		return "", f.error(senderCode, errors.New("sender code must be 10 digits"))
 	}
 	return senderCode, nil
 }
 
-func parseAccountType(accountType string) (types.AccountType, error) {
-	switch accountType {
-	case "1":
-		return types.AccountTypeRegular, nil
-	case "2":
-		return types.AccountTypeChecking, nil
-	case "4":
-		return types.AccountTypeSavings, nil
-	default:
-		return types.AccountTypeUndefined, errors.New("invalid account type: " + accountType)
+// parseCompanyCode parses the 会社コード of 給与・賞与振込, assigned by the bank
+func parseCompanyCode(line record, f field) (string, error) {
+	companyCode := f.value(line)
+	if len(companyCode) != 10 || strings.Trim(companyCode, "0123456789") != "" {
+		return "", f.error(companyCode, errors.New("company code must be 10 digits"))
+	}
+	return companyCode, nil
+}
+
+func parseConsignorCode(line record, f field) (string, error) {
+	consignorCode := f.value(line)
+	if len(consignorCode) != 10 || strings.Trim(consignorCode, "0123456789") != "" {
+		return "", f.error(consignorCode, errors.New("consignor code must be 10 digits"))
+	}
+	return consignorCode, nil
+}
+
+func parseAccountType(line record, f field) (types.AccountType, error) {
+	accountType := f.value(line)
+	t := types.ParseAccountType(accountType)
+	if t == types.AccountTypeUndefined {
+		return t, f.error(accountType, errors.New("invalid account type: "+accountType))
+	}
+	return t, nil
+}
+
+func parseAccountNumber(line record, f field) (string, error) {
+	accountNumber := f.value(line)
+	if _, err := strconv.Atoi(accountNumber); err != nil {
+		return "", f.error(accountNumber, errors.New("invalid account number: contains non-numeric characters"))
 	}
+	return accountNumber, nil
 }
 
-func parseNewCode(accountType string) (types.NewCode, error) {
-	switch accountType {
+func parseNewCode(line record, f field) (types.NewCode, error) {
+	newCode := f.value(line)
+	switch newCode {
 	case "1":
 		return types.CodeFirstTransfer, nil
 	case "2":
@@ -85,34 +223,143 @@ func parseNewCode(accountType string) (types.NewCode, error) {
 	case "0":
 		return types.CodeOther, nil
 	default:
-		return types.CodeUndefined, errors.New("invalid account type: " + accountType)
+		return types.CodeUndefined, f.error(newCode, errors.New("invalid new code: "+newCode))
 	}
 }
 
-func parseDate(date string) (string, error) {
-	_, err := time.Parse("0102", date)
+// parseResultCode parses a 振替結果コード, which is "0" in request files or left blank by some of them
+func parseResultCode(line record, f field) (types.ResultCode, error) {
+	resultCode := f.value(line)
+	code := types.ParseResultCode(resultCode)
+	if code == types.ResultCodeUndefined {
+		return code, f.error(resultCode, errors.New("invalid result code: "+resultCode))
+	}
+	return code, nil
+}
+
+func parseEntryType(line record, f field) (types.EntryType, error) {
+	entryType := f.value(line)
+	switch entryType {
+	case "1":
+		return types.EntryTypeDeposit, nil
+	case "2":
+		return types.EntryTypeWithdrawal, nil
+	default:
+		return types.EntryTypeUndefined, f.error(entryType, errors.New("invalid entry type: "+entryType))
+	}
+}
+
+func parseTransactionType(line record, f field) (types.TransactionType, error) {
+	transactionType := f.value(line)
+	n, _ := strconv.Atoi(transactionType)
+	switch t := types.TransactionType(n); t {
+	case types.TransactionTypeCash, types.TransactionTypeTransfer, types.TransactionTypeOtherBank,
+		types.TransactionTypeClearing, types.TransactionTypeBookEntry, types.TransactionTypeOther,
+		types.TransactionTypeCorrection:
+		return t, nil
+	default:
+		return types.TransactionTypeUndefined, f.error(transactionType, errors.New("invalid transaction type: "+transactionType))
+	}
+}
+
+func parseDate(line record, f field) (string, error) {
+	date := f.value(line)
+	if err := checkDate(date); err != nil {
+		return "", f.error(date, fmt.Errorf("invalid date: %w", err))
+	}
+	return date, nil
+}
+
+// parseOptionalLongDate parses a YYMMDD date that may be left blank
+func parseOptionalLongDate(line record, f field) (string, error) {
+	if date := f.value(line); strings.TrimSpace(date) == "" {
+		return date, nil
+	}
+	return parseLongDate(line, f)
+}
+
+// parseBalance parses a balance preceded by its 貸越区分, "1" if it is positive and "2" if it is negative
+func parseBalance(line record, overdraft field, f field) (int64, error) {
+	balance, err := parseAmount(line, f)
 	if err != nil {
-		return "", err
+		return 0, err
+	}
+	switch sign := overdraft.value(line); sign {
+	case "1":
+		return int64(balance), nil
+	case "2":
+		return -int64(balance), nil
+	default:
+		return 0, overdraft.error(sign, errors.New("invalid overdraft category: "+sign))
+	}
+}
+
+// parseLongDate parses a YYMMDD date, where the year may be in the Japanese era
+func parseLongDate(line record, f field) (string, error) {
+	date := f.value(line)
+	if len(date) != 6 || strings.Trim(date[0:2], "0123456789") != "" {
+		return "", f.error(date, errors.New("invalid date: must be 6 digits"))
+	}
+	if err := checkDate(date[2:]); err != nil {
+		return "", f.error(date, fmt.Errorf("invalid date: %w", err))
 	}
 	return date, nil
 }
-func parseBankCode(bankCode string) (string, error) {
+
+// checkDate returns an error if date is not a valid MMDD date
+func checkDate(date string) error {
+	_, err := time.Parse("0102", date)
+	return err
+}
+
+func parseBankCode(line record, f field) (string, error) {
+	bankCode := f.value(line)
 	if len(bankCode) != 4 {
-		return "", errors.New("bank code must be 4 digits")
+		return "", f.error(bankCode, errors.New("bank code must be 4 digits"))
 	}
 	if _, err := strconv.Atoi(bankCode); err != nil {
-		return "", errors.New("invalid bank code: contains non-numeric characters: " +
-			bankCode + "Error: " + err.Error())
+		return "", f.error(bankCode, errors.New("invalid bank code: contains non-numeric characters"))
 	}
 	return bankCode, nil
 }
 
-func parseBranchCode(branchCode string) (string, error) {
+func parseBranchCode(line record, f field) (string, error) {
+	branchCode := f.value(line)
 	if len(branchCode) != 3 {
-		return "", errors.New("branch code must be 3 digits")
+		return "", f.error(branchCode, errors.New("branch code must be 3 digits"))
 	}
 	if _, err := strconv.Atoi(branchCode); err != nil {
-		return "", errors.New("invalid branch code: contains non-numeric characters: " + branchCode)
+		return "", f.error(branchCode, errors.New("invalid branch code: contains non-numeric characters"))
 	}
 	return branchCode, nil
 }
+
+// parseOptionalCode parses a numeric code that may be left blank
+func parseOptionalCode(line record, f field) (string, error) {
+	code := f.value(line)
+	if strings.TrimSpace(code) == "" {
+		return code, nil
+	}
+	if _, err := strconv.Atoi(code); err != nil {
+		return "", f.error(code, errors.New("contains non-numeric characters"))
+	}
+	return code, nil
+}
+
+func parseCount(line record, f field) (int, error) {
+	count := f.value(line)
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, f.error(count, fmt.Errorf("invalid count: %w", err))
+	}
+	return n, nil
+}
+
+func parseAmount(line record, f field) (uint64, error) {
+	amount := f.value(line)
+	n, err := strconv.ParseUint(amount, 10, 64)
+	if err != nil {
+		return 0, f.error(amount, fmt.Errorf("invalid amount: %w", err))
+	}
+	return n, nil
+}
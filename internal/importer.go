@@ -0,0 +1,285 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
+	"github.com/Kyash/zengin-go/types"
+	"golang.org/x/text/width"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// importField is a field of a data record read from a CSV column
+type importField struct {
+	name     string
+	required bool
+	aliases  []string // headers matched by default, besides name
+}
+
+// importFields are the fields a CSVReader reads, with the headers written by TableWriter and ToCSVJa
+// and the usual headers of spreadsheets as aliases
+var importFields = []importField{
+	{"RecipientBankCode", true, []string{"被仕向金融機関番号", "金融機関コード", "銀行コード", "金融機関番号", "BankCode"}},
+	{"RecipientBankName", false, []string{"被仕向金融機関名", "金融機関名", "銀行名", "BankName"}},
+	{"RecipientBranchCode", true, []string{"被仕向支店番号", "支店コード", "支店番号", "店番", "BranchCode"}},
+	{"RecipientBranchName", false, []string{"被仕向支店名", "支店名", "BranchName"}},
+	{"RecipientAccountType", true, []string{"受取人預金種目", "預金種目", "科目", "口座種別", "AccountType"}},
+	{"RecipientAccountNumber", true, []string{"受取人口座番号", "口座番号", "AccountNumber"}},
+	{"RecipientName", true, []string{"受取人名", "口座名義人", "口座名義", "受取人", "AccountName"}},
+	{"Amount", true, []string{"振込金額", "金額"}},
+	{"CustomerCode1", false, []string{"顧客コード1"}},
+	{"CustomerCode2", false, []string{"顧客コード2"}},
+	{"EdiInformation", false, []string{"EDI情報"}},
+}
+
+// CSVReader reads data records from a CSV file with a header row, such as a spreadsheet export.
+// Columns are found by their header, see SetColumn. Names are normalized with kana.Normalize,
+// amounts may have commas, full-width digits and 円, and account types may be labels such as 普通.
+type CSVReader struct {
+	reader   io.Reader
+	comma    rune
+	encoding types.Encoding
+	columns  map[string]string // header of the fields set by SetColumn
+}
+
+func NewCSVReader(reader io.Reader) *CSVReader {
+	return &CSVReader{reader: reader, comma: ',', columns: map[string]string{}}
+}
+
+// SetComma sets the separator of the columns, ',' by default and '\t' for TSV
+func (r *CSVReader) SetComma(comma rune) {
+	r.comma = comma
+}
+
+// SetEncoding reads the file in types.EncodingUTF8, types.EncodingShiftJIS or a registered encoding.
+// By default the file is read as UTF-8 if it is valid UTF-8, and as Shift-JIS otherwise.
+func (r *CSVReader) SetEncoding(encoding types.Encoding) {
+	r.encoding = encoding
+}
+
+// SetColumn reads a field from the column with the given header instead of the default ones.
+// The fields are RecipientBankCode, RecipientBankName, RecipientBranchCode, RecipientBranchName,
+// RecipientAccountType, RecipientAccountNumber, RecipientName, Amount, CustomerCode1, CustomerCode2 and EdiInformation.
+func (r *CSVReader) SetColumn(field, header string) error {
+	for _, f := range importFields {
+		if f.name == field {
+			r.columns[field] = header
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown field: %s", field)
+}
+
+// Read returns the data records of every row. Every row is checked as the Encoder would write it,
+// and the errors of all the rows are returned together as *types.RowError, see errors.As.
+func (r *CSVReader) Read() ([]types.Data, error) {
+	content, err := r.decode()
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = r.comma
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no header row")
+	}
+
+	indexes, err := r.findColumns(rows[0])
+	if err != nil {
+		return nil, err
+	}
+	var data []types.Data
+	var errs []error
+	for i, row := range rows[1:] {
+		if strings.Join(row, "") == "" {
+			continue // blank row, left by spreadsheets at the end
+		}
+		d, err := readRow(i+2, rows[0], row, indexes)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data = append(data, d)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return data, nil
+}
+
+// decode reads the whole file as a string without its byte order mark
+func (r *CSVReader) decode() (string, error) {
+	content, err := io.ReadAll(r.reader)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+	}
+	encoding := r.encoding
+	if encoding == types.EncodingUndefined {
+		encoding = types.EncodingUTF8
+		if !utf8.Valid(content) {
+			encoding = types.EncodingShiftJIS
+		}
+	}
+	if encoding != types.EncodingUTF8 {
+		e, err := lookupEncoding(encoding)
+		if err != nil {
+			return "", err
+		}
+		if content, err = e.NewDecoder().Bytes(content); err != nil {
+			return "", fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+		}
+	}
+	return string(bytes.TrimPrefix(content, []byte("\uFEFF"))), nil
+}
+
+// findColumns returns the index of the column of every field in the header row, -1 for missing optional fields
+func (r *CSVReader) findColumns(header []string) (map[string]int, error) {
+	indexes := map[string]int{}
+	for _, f := range importFields {
+		names := append([]string{f.name}, f.aliases...)
+		if name, ok := r.columns[f.name]; ok {
+			names = []string{name}
+		}
+		indexes[f.name] = -1
+		for i, h := range header {
+			h = strings.TrimSpace(width.Narrow.String(h))
+			for _, name := range names {
+				if strings.EqualFold(h, width.Narrow.String(name)) {
+					indexes[f.name] = i
+				}
+			}
+			if indexes[f.name] >= 0 {
+				break
+			}
+		}
+		if f.required && indexes[f.name] < 0 {
+			return nil, fmt.Errorf("no column for %s, see SetColumn", f.name)
+		}
+	}
+	return indexes, nil
+}
+
+// readRow returns the data record of a row, or a *types.RowError for the first invalid column
+func readRow(line int, header, row []string, indexes map[string]int) (types.Data, error) {
+	value := func(field string) string {
+		if i, ok := indexes[field]; ok && i >= 0 && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	rowError := func(field string, err error) error {
+		e := &types.RowError{Row: line, Field: field, Value: value(field), Err: err}
+		if i, ok := indexes[field]; ok && i >= 0 {
+			e.Column = header[i]
+		}
+		return e
+	}
+
+	var d types.Data
+	var err error
+	if d.RecipientBankCode, err = importCode(value("RecipientBankCode"), 4); err != nil {
+		return d, rowError("RecipientBankCode", err)
+	}
+	d.RecipientBankName = importName(value("RecipientBankName"))
+	if d.RecipientBranchCode, err = importCode(value("RecipientBranchCode"), 3); err != nil {
+		return d, rowError("RecipientBranchCode", err)
+	}
+	d.RecipientBranchName = importName(value("RecipientBranchName"))
+	if d.RecipientAccountType, err = importAccountType(value("RecipientAccountType")); err != nil {
+		return d, rowError("RecipientAccountType", err)
+	}
+	if d.RecipientAccountNumber, err = importCode(value("RecipientAccountNumber"), 7); err != nil {
+		return d, rowError("RecipientAccountNumber", err)
+	}
+	d.RecipientName = importName(value("RecipientName"))
+	if d.RecipientName == "" {
+		return d, rowError("RecipientName", errors.New("name is required"))
+	}
+	if d.Amount, err = importAmount(value("Amount")); err != nil {
+		return d, rowError("Amount", err)
+	}
+
+	if edi := strings.TrimSpace(value("EdiInformation")); edi != "" {
+		if err := d.SetEdiInformation(edi); err != nil {
+			return d, rowError("EdiInformation", err)
+		}
+	} else {
+		var codes [2]string
+		for i, field := range []string{"CustomerCode1", "CustomerCode2"} {
+			if strings.TrimSpace(value(field)) == "" {
+				continue
+			}
+			if codes[i], err = importCode(value(field), 10); err != nil {
+				return d, rowError(field, err)
+			}
+		}
+		if err := d.SetCustomerCodes(codes[0], codes[1]); err != nil {
+			return d, &types.RowError{Row: line, Err: err}
+		}
+	}
+
+	if _, err := formatData(d); err != nil {
+		var parseError *types.ParseError
+		if errors.As(err, &parseError) {
+			field := parseError.Field
+			if field == "Extra" && d.EdiPresent {
+				field = "EdiInformation" // the column Extra was read from
+			}
+			return d, rowError(field, parseError.Err)
+		}
+		return d, &types.RowError{Row: line, Err: err}
+	}
+	return d, nil
+}
+
+// importCode returns the digits of a code with full-width digits narrowed, padded with zeros to length
+// as spreadsheets drop the leading zeros of numbers
+func importCode(value string, length int) (string, error) {
+	code := strings.TrimSpace(width.Narrow.String(value))
+	if code == "" || strings.Trim(code, "0123456789") != "" {
+		return code, errors.New("must be digits")
+	}
+	if len(code) < length {
+		code = strings.Repeat("0", length-len(code)) + code
+	}
+	return code, nil
+}
+
+// importName returns a name normalized to the Zengin character set, with consecutive spaces merged
+func importName(value string) string {
+	return strings.Join(strings.Fields(kana.Normalize(value)), " ")
+}
+
+// importAmount parses an amount such as "10,000", "１００００" or "10,000円"
+func importAmount(value string) (uint64, error) {
+	amount := strings.NewReplacer(",", "", "円", "", "¥", "", "\\", "", " ", "").Replace(width.Narrow.String(value))
+	n, err := strconv.ParseUint(amount, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount")
+	}
+	if n == 0 {
+		return 0, errors.New("amount must be positive")
+	}
+	return n, nil
+}
+
+// importAccountType parses an account type written as its digit, its 預金種目 such as 普通 or its English name
+func importAccountType(value string) (types.AccountType, error) {
+	value = strings.TrimSpace(width.Narrow.String(value))
+	for _, t := range []types.AccountType{types.AccountTypeRegular, types.AccountTypeChecking, types.AccountTypeSavings, types.AccountTypeOther} {
+		if value == strconv.Itoa(int(t)) || value == t.Description() || value == t.Description()+"預金" ||
+			strings.EqualFold(value, t.Name()) {
+			return t, nil
+		}
+	}
+	return types.AccountTypeUndefined, errors.New("unknown account type")
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"encoding/json"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strings"
+	"time"
+)
+
+// jsonHeader is the JSON form of a types.Header. Every code is a zero-padded string and names have their padding trimmed.
+type jsonHeader struct {
+	CategoryCode         string `json:"category_code"`                    // 種別コード, such as "21"
+	Category             string `json:"category"`                         // "combination", "payment", "bonus" or 総合振込, 給与振込, 賞与振込
+	EncodingType         string `json:"encoding_type"`                    // コード区分 as in the record: "0" (JIS) in Shift-JIS files, "1" (EBCDIC in the specification) in the others
+	SenderCode           string `json:"sender_code"`                      // 10 digits, 会社コード in 給与・賞与振込
+	SenderName           string `json:"sender_name"`                      // 会社名 in 給与・賞与振込
+	TransferDate         string `json:"transfer_date"`                    // MMDD
+	ResolvedTransferDate string `json:"resolved_transfer_date,omitempty"` // YYYY-MM-DD, if the date was resolved
+	SenderBankCode       string `json:"sender_bank_code"`                 // 4 digits
+	SenderBankName       string `json:"sender_bank_name"`
+	SenderBranchCode     string `json:"sender_branch_code"` // 3 digits
+	SenderBranchName     string `json:"sender_branch_name"`
+	SenderAccountType    string `json:"sender_account_type"`   // "regular", "checking", "savings", "other" or 普通, 当座, 貯蓄, その他
+	SenderAccountNumber  string `json:"sender_account_number"` // 7 digits
+}
+
+// jsonData is the JSON form of a types.Data
+type jsonData struct {
+	RecipientBankCode      string `json:"recipient_bank_code"` // 4 digits
+	RecipientBankName      string `json:"recipient_bank_name"`
+	RecipientBranchCode    string `json:"recipient_branch_code"` // 3 digits
+	RecipientBranchName    string `json:"recipient_branch_name"`
+	ExchangeOfficeCode     string `json:"exchange_office_code"`     // 4 digits or blank
+	RecipientAccountType   string `json:"recipient_account_type"`   // as sender_account_type
+	RecipientAccountNumber string `json:"recipient_account_number"` // 7 digits
+	RecipientName          string `json:"recipient_name"`
+	Amount                 uint64 `json:"amount"`
+	NewCode                string `json:"new_code"` // "first_transfer", "update_transfer", "other" or 第1回振込分, 変更分, その他
+	Extra                  string `json:"extra"`    // 顧客コード1/2 or EDI情報, as in the record
+	EdiPresent             bool   `json:"edi_present"`
+	TransferCategory       string `json:"transfer_category"` // 振込指定区分, 1 digit or blank
+}
+
+// jsonTrailer is the JSON form of a types.Trailer
+type jsonTrailer struct {
+	TotalCount  int    `json:"total_count"`
+	TotalAmount uint64 `json:"total_amount"`
+}
+
+// jsonTransfer is the JSON form of a types.Transfer, the keys of its header, data and trailer in a single object
+type jsonTransfer struct {
+	jsonHeader
+	jsonData
+	*jsonTrailer // left out for transfers read by a Decoder, which have no trailer yet
+}
+
+// jsonGroup is the JSON form of a types.Group
+type jsonGroup struct {
+	Header  jsonHeader  `json:"header"`
+	Data    []jsonData  `json:"data"`
+	Trailer jsonTrailer `json:"trailer"`
+}
+
+// JSONEncoder writes transfers or header groups as a JSON array, or as newline-delimited JSON with one object per line.
+// Keys are the snake_case names of the types fields, and enums are written as their English names
+// or, with SetJapanese, as their descriptions in the Zengin specification.
+type JSONEncoder struct {
+	writer   io.Writer
+	lines    bool
+	japanese bool
+	count    int
+}
+
+func NewJSONEncoder(writer io.Writer, lines bool) *JSONEncoder {
+	return &JSONEncoder{writer: writer, lines: lines}
+}
+
+// SetJapanese writes enums in Japanese, such as 普通 instead of "regular". Keys are always in English.
+func (e *JSONEncoder) SetJapanese(japanese bool) {
+	e.japanese = japanese
+}
+
+// EncodeTransfer writes a transfer, with its trailer totals unless its trailer is empty
+func (e *JSONEncoder) EncodeTransfer(transfer types.Transfer) error {
+	v := jsonTransfer{jsonHeader: e.header(transfer.Header), jsonData: e.data(transfer.Data)}
+	if transfer.Trailer != (types.Trailer{}) {
+		v.jsonTrailer = &jsonTrailer{TotalCount: transfer.TotalCount, TotalAmount: transfer.TotalAmount}
+	}
+	return e.encode(v)
+}
+
+// EncodeGroup writes a header group as an object with "header", "data" and "trailer" keys
+func (e *JSONEncoder) EncodeGroup(group types.Group) error {
+	v := jsonGroup{
+		Header:  e.header(group.Header),
+		Data:    make([]jsonData, len(group.Data)),
+		Trailer: jsonTrailer{TotalCount: group.Trailer.TotalCount, TotalAmount: group.Trailer.TotalAmount},
+	}
+	for i, data := range group.Data {
+		v.Data[i] = e.data(data)
+	}
+	return e.encode(v)
+}
+
+// Close ends the JSON array, writing an empty one if nothing was encoded. It doesn't close the underlying writer.
+func (e *JSONEncoder) Close() error {
+	if e.lines {
+		return nil
+	}
+	end := "]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.writer, end)
+	return err
+}
+
+func (e *JSONEncoder) encode(v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.lines:
+		encoded = append(encoded, '\n')
+	case e.count == 0:
+		encoded = append([]byte("[\n"), encoded...)
+	default:
+		encoded = append([]byte(",\n"), encoded...)
+	}
+	if _, err := e.writer.Write(encoded); err != nil {
+		return err
+	}
+	e.count++
+	return nil
+}
+
+func (e *JSONEncoder) header(header types.Header) jsonHeader {
+	v := jsonHeader{
+		CategoryCode:        header.RawCategoryCode,
+		Category:            header.CategoryCode.Name(),
+		EncodingType:        header.EncodingType,
+		SenderCode:          header.SenderCode,
+		SenderName:          trimName(header.SenderName),
+		TransferDate:        header.TransferDate,
+		SenderBankCode:      header.SenderBankCode,
+		SenderBankName:      trimName(header.SenderBankName),
+		SenderBranchCode:    header.SenderBranchCode,
+		SenderBranchName:    trimName(header.SenderBranchName),
+		SenderAccountType:   header.SenderAccountType.Name(),
+		SenderAccountNumber: header.SenderAccountNumber,
+	}
+	if v.CategoryCode == "" {
+		v.CategoryCode = header.CategoryCode.Code()
+	}
+	if !header.ResolvedTransferDate.IsZero() {
+		v.ResolvedTransferDate = header.ResolvedTransferDate.Format(time.DateOnly)
+	}
+	if e.japanese {
+		v.Category = header.CategoryCode.Description()
+		v.SenderAccountType = header.SenderAccountType.Description()
+	}
+	return v
+}
+
+func (e *JSONEncoder) data(data types.Data) jsonData {
+	v := jsonData{
+		RecipientBankCode:      data.RecipientBankCode,
+		RecipientBankName:      trimName(data.RecipientBankName),
+		RecipientBranchCode:    data.RecipientBranchCode,
+		RecipientBranchName:    trimName(data.RecipientBranchName),
+		ExchangeOfficeCode:     strings.TrimSpace(data.ExchangeOfficeCode),
+		RecipientAccountType:   data.RecipientAccountType.Name(),
+		RecipientAccountNumber: data.RecipientAccountNumber,
+		RecipientName:          trimName(data.RecipientName),
+		Amount:                 data.Amount,
+		NewCode:                data.NewCode.Name(),
+		Extra:                  trimName(data.Extra),
+		EdiPresent:             data.EdiPresent,
+		TransferCategory:       strings.TrimSpace(data.TransferCategory),
+	}
+	if e.japanese {
+		v.RecipientAccountType = data.RecipientAccountType.Description()
+		v.NewCode = data.NewCode.Description()
+	}
+	return v
+}
+
+// trimName removes the padding of a name
+func trimName(name string) string {
+	return strings.TrimRight(name, " ")
+}
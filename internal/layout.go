@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"github.com/Kyash/zengin-go/types"
+	"golang.org/x/text/encoding"
+	"unicode/utf8"
+)
+
+// record is one record of a file. In Shift-JIS and registered encodings its fields are cut from the raw bytes
+// and decoded one by one, as the Zengin specification counts positions in bytes,
+// so that a double-byte character can't shift the fields after it. In UTF-8 positions are characters.
+type record struct {
+	runes   []rune            // whole record, decoded
+	raw     []byte            // raw bytes of the record, nil for UTF-8
+	decoder *encoding.Decoder // nil for UTF-8
+}
+
+func newRecord(raw []byte, decoder *encoding.Decoder) (record, error) {
+	if decoder == nil {
+		return record{runes: []rune(string(raw))}, nil
+	}
+	decoded, err := decoder.Bytes(raw)
+	if err != nil {
+		return record{}, err
+	}
+	return record{runes: []rune(string(decoded)), raw: append([]byte(nil), raw...), decoder: decoder}, nil
+}
+
+// newStringRecord returns a record of a string counted in characters, such as a formatted record
+func newStringRecord(s string) record {
+	return record{runes: []rune(s)}
+}
+
+// len returns the length of the record in bytes, or in characters for UTF-8
+func (r record) len() int {
+	if r.decoder == nil {
+		return len(r.runes)
+	}
+	return len(r.raw)
+}
+
+func (r record) slice(start, end int) string {
+	if r.decoder == nil {
+		return string(r.runes[start:end])
+	}
+	decoded, err := r.decoder.Bytes(r.raw[start:end])
+	if err != nil {
+		return string(utf8.RuneError)
+	}
+	return string(decoded)
+}
+
+func (r record) String() string {
+	return string(r.runes)
+}
+
+// field is the position of a field in a record, in bytes or in characters for UTF-8
+type field struct {
+	recordType string
+	name       string // name of the field in the types structs
+	nameJa     string // name of the field in the Zengin specification
+	start      int
+	end        int
+}
+
+// in reports whether line is long enough to contain the field
+func (f field) in(line record) bool {
+	return line.len() >= f.end
+}
+
+func (f field) value(line record) string {
+	return line.slice(f.start, f.end)
+}
+
+// error returns a *types.ParseError for an invalid value of the field
+func (f field) error(value string, err error) *types.ParseError {
+	return &types.ParseError{
+		RecordType: f.recordType,
+		Field:      f.name,
+		FieldJa:    f.nameJa,
+		Start:      f.start,
+		End:        f.end,
+		Value:      value,
+		Err:        err,
+	}
+}
+
+// 総合振込 header record
+var (
+	headerRecordType          = field{"1", "RecordType", "データ区分", 0, 1}
+	headerCategoryCode        = field{"1", "CategoryCode", "種別コード", 1, 3}
+	headerEncodingType        = field{"1", "EncodingType", "コード区分", 3, 4}
+	headerSenderCode          = field{"1", "SenderCode", "振込依頼人コード", 4, 14}
+	headerSenderName          = field{"1", "SenderName", "振込依頼人名", 14, 54}
+	headerTransferDate        = field{"1", "TransferDate", "振込指定日", 54, 58}
+	headerSenderBankCode      = field{"1", "SenderBankCode", "仕向金融機関番号", 58, 62}
+	headerSenderBankName      = field{"1", "SenderBankName", "仕向金融機関名", 62, 77}
+	headerSenderBranchCode    = field{"1", "SenderBranchCode", "仕向支店番号", 77, 80}
+	headerSenderBranchName    = field{"1", "SenderBranchName", "仕向支店名", 80, 95}
+	headerSenderAccountType   = field{"1", "SenderAccountType", "依頼人預金種目", 95, 96}
+	headerSenderAccountNumber = field{"1", "SenderAccountNumber", "依頼人口座番号", 96, 103}
+	headerDummy               = field{"1", "Dummy", "ダミー", 103, 120}
+
+	// 給与・賞与振込 name the sender fields after the company
+	headerCompanyCode = field{"1", "SenderCode", "会社コード", 4, 14}
+	headerCompanyName = field{"1", "SenderName", "会社名", 14, 54}
+)
+
+// 総合振込 data record
+var (
+	dataRecordType             = field{"2", "RecordType", "データ区分", 0, 1}
+	dataRecipientBankCode      = field{"2", "RecipientBankCode", "被仕向金融機関番号", 1, 5}
+	dataRecipientBankName      = field{"2", "RecipientBankName", "被仕向金融機関名", 5, 20}
+	dataRecipientBranchCode    = field{"2", "RecipientBranchCode", "被仕向支店番号", 20, 23}
+	dataRecipientBranchName    = field{"2", "RecipientBranchName", "被仕向支店名", 23, 38}
+	dataExchangeOfficeCode     = field{"2", "ExchangeOfficeCode", "手形交換所番号", 38, 42}
+	dataRecipientAccountType   = field{"2", "RecipientAccountType", "受取人預金種目", 42, 43}
+	dataRecipientAccountNumber = field{"2", "RecipientAccountNumber", "受取人口座番号", 43, 50}
+	dataRecipientName          = field{"2", "RecipientName", "受取人名", 50, 80}
+	dataAmount                 = field{"2", "Amount", "振込金額", 80, 90}
+	dataNewCode                = field{"2", "NewCode", "新規コード", 90, 91}
+	dataExtra                  = field{"2", "Extra", "顧客コード・EDI情報", 91, 111}
+	dataTransferCategory       = field{"2", "TransferCategory", "振込指定区分", 111, 112}
+	dataEdiPresent             = field{"2", "EdiPresent", "識別表示", 112, 113}
+	dataDummy                  = field{"2", "Dummy", "ダミー", 113, 120}
+)
+
+// 総合振込 trailer record
+var (
+	trailerRecordType  = field{"8", "RecordType", "データ区分", 0, 1}
+	trailerTotalCount  = field{"8", "TotalCount", "合計件数", 1, 7}
+	trailerTotalAmount = field{"8", "TotalAmount", "合計金額", 7, 19}
+	trailerDummy       = field{"8", "Dummy", "ダミー", 19, 120}
+)
+
+// end record
+var (
+	endRecordType = field{"9", "RecordType", "データ区分", 0, 1}
+	endDummy      = field{"9", "Dummy", "ダミー", 1, 120}
+)
+
+// 口座振替 header record
+var (
+	debitHeaderRecordType    = field{"1", "RecordType", "データ区分", 0, 1}
+	debitHeaderCategoryCode  = field{"1", "CategoryCode", "種別コード", 1, 3}
+	debitHeaderEncodingType  = field{"1", "EncodingType", "コード区分", 3, 4}
+	debitHeaderConsignorCode = field{"1", "ConsignorCode", "委託者コード", 4, 14}
+	debitHeaderConsignorName = field{"1", "ConsignorName", "委託者名", 14, 54}
+	debitHeaderDebitDate     = field{"1", "DebitDate", "引落日", 54, 58}
+	debitHeaderBankCode      = field{"1", "BankCode", "取引銀行番号", 58, 62}
+	debitHeaderBankName      = field{"1", "BankName", "取引銀行名", 62, 77}
+	debitHeaderBranchCode    = field{"1", "BranchCode", "取引支店番号", 77, 80}
+	debitHeaderBranchName    = field{"1", "BranchName", "取引支店名", 80, 95}
+	debitHeaderAccountType   = field{"1", "AccountType", "預金種目(委託者)", 95, 96}
+	debitHeaderAccountNumber = field{"1", "AccountNumber", "口座番号(委託者)", 96, 103}
+	debitHeaderDummy         = field{"1", "Dummy", "ダミー", 103, 120}
+)
+
+// 口座振替 data record
+var (
+	debitDataRecordType     = field{"2", "RecordType", "データ区分", 0, 1}
+	debitDataBankCode       = field{"2", "BankCode", "引落銀行番号", 1, 5}
+	debitDataBankName       = field{"2", "BankName", "引落銀行名", 5, 20}
+	debitDataBranchCode     = field{"2", "BranchCode", "引落支店番号", 20, 23}
+	debitDataBranchName     = field{"2", "BranchName", "引落支店名", 23, 38}
+	debitDataDummy1         = field{"2", "Dummy1", "ダミー", 38, 42}
+	debitDataAccountType    = field{"2", "AccountType", "預金種目", 42, 43}
+	debitDataAccountNumber  = field{"2", "AccountNumber", "口座番号", 43, 50}
+	debitDataAccountName    = field{"2", "AccountName", "預金者名", 50, 80}
+	debitDataAmount         = field{"2", "Amount", "引落金額", 80, 90}
+	debitDataNewCode        = field{"2", "NewCode", "新規コード", 90, 91}
+	debitDataCustomerNumber = field{"2", "CustomerNumber", "顧客番号", 91, 111}
+	debitDataResultCode     = field{"2", "ResultCode", "振替結果コード", 111, 112}
+	debitDataDummy2         = field{"2", "Dummy2", "ダミー", 112, 120}
+)
+
+// 口座振替 trailer record
+var (
+	debitTrailerRecordType        = field{"8", "RecordType", "データ区分", 0, 1}
+	debitTrailerTotalCount        = field{"8", "TotalCount", "合計件数", 1, 7}
+	debitTrailerTotalAmount       = field{"8", "TotalAmount", "合計金額", 7, 19}
+	debitTrailerTransferredCount  = field{"8", "TransferredCount", "振替済件数", 19, 25}
+	debitTrailerTransferredAmount = field{"8", "TransferredAmount", "振替済金額", 25, 37}
+	debitTrailerFailedCount       = field{"8", "FailedCount", "振替不能件数", 37, 43}
+	debitTrailerFailedAmount      = field{"8", "FailedAmount", "振替不能金額", 43, 55}
+	debitTrailerDummy             = field{"8", "Dummy", "ダミー", 55, 120}
+)
+
+// 振込入金通知 header record
+var (
+	notificationHeaderRecordType         = field{"1", "RecordType", "データ区分", 0, 1}
+	notificationHeaderCategoryCode       = field{"1", "CategoryCode", "種別コード", 1, 3}
+	notificationHeaderEncodingType       = field{"1", "EncodingType", "コード区分", 3, 4}
+	notificationHeaderCreatedDate        = field{"1", "CreatedDate", "作成日", 4, 10}
+	notificationHeaderAccountingDateFrom = field{"1", "AccountingDateFrom", "勘定日(自)", 10, 16}
+	notificationHeaderAccountingDateTo   = field{"1", "AccountingDateTo", "勘定日(至)", 16, 22}
+	notificationHeaderBankCode           = field{"1", "BankCode", "銀行コード", 22, 26}
+	notificationHeaderBankName           = field{"1", "BankName", "銀行名", 26, 41}
+	notificationHeaderBranchCode         = field{"1", "BranchCode", "支店コード", 41, 44}
+	notificationHeaderBranchName         = field{"1", "BranchName", "支店名", 44, 59}
+	notificationHeaderDummy1             = field{"1", "Dummy1", "ダミー", 59, 62}
+	notificationHeaderAccountType        = field{"1", "AccountType", "預金種目", 62, 63}
+	notificationHeaderAccountNumber      = field{"1", "AccountNumber", "口座番号", 63, 73}
+	notificationHeaderAccountName        = field{"1", "AccountName", "口座名", 73, 113}
+	notificationHeaderDummy2             = field{"1", "Dummy2", "ダミー", 113, 200}
+)
+
+// 振込入金通知 data record
+var (
+	notificationDataRecordType           = field{"2", "RecordType", "データ区分", 0, 1}
+	notificationDataInquiryNumber        = field{"2", "InquiryNumber", "照会番号", 1, 7}
+	notificationDataAccountingDate       = field{"2", "AccountingDate", "勘定日", 7, 13}
+	notificationDataValueDate            = field{"2", "ValueDate", "起算日", 13, 19}
+	notificationDataAmount               = field{"2", "Amount", "金額", 19, 29}
+	notificationDataOtherBankCheckAmount = field{"2", "OtherBankCheckAmount", "うち他店券金額", 29, 39}
+	notificationDataSenderCode           = field{"2", "SenderCode", "振込依頼人コード", 39, 49}
+	notificationDataSenderName           = field{"2", "SenderName", "振込依頼人名", 49, 97}
+	notificationDataSenderBankName       = field{"2", "SenderBankName", "仕向銀行名", 97, 112}
+	notificationDataSenderBranchName     = field{"2", "SenderBranchName", "仕向店名", 112, 127}
+	notificationDataCancelled            = field{"2", "Cancelled", "取消区分", 127, 128}
+	notificationDataEdiInformation       = field{"2", "EdiInformation", "EDI情報", 128, 148}
+	notificationDataDummy                = field{"2", "Dummy", "ダミー", 148, 200}
+)
+
+// 振込入金通知 trailer record
+var (
+	notificationTrailerRecordType      = field{"8", "RecordType", "データ区分", 0, 1}
+	notificationTrailerTotalCount      = field{"8", "TotalCount", "振込合計件数", 1, 7}
+	notificationTrailerTotalAmount     = field{"8", "TotalAmount", "振込合計金額", 7, 19}
+	notificationTrailerCancelledCount  = field{"8", "CancelledCount", "取消合計件数", 19, 25}
+	notificationTrailerCancelledAmount = field{"8", "CancelledAmount", "取消合計金額", 25, 37}
+	notificationTrailerDummy           = field{"8", "Dummy", "ダミー", 37, 200}
+)
+
+// 入出金取引明細 header record
+var (
+	statementHeaderRecordType         = field{"1", "RecordType", "データ区分", 0, 1}
+	statementHeaderCategoryCode       = field{"1", "CategoryCode", "種別コード", 1, 3}
+	statementHeaderEncodingType       = field{"1", "EncodingType", "コード区分", 3, 4}
+	statementHeaderCreatedDate        = field{"1", "CreatedDate", "作成日", 4, 10}
+	statementHeaderAccountingDateFrom = field{"1", "AccountingDateFrom", "勘定日(自)", 10, 16}
+	statementHeaderAccountingDateTo   = field{"1", "AccountingDateTo", "勘定日(至)", 16, 22}
+	statementHeaderBankCode           = field{"1", "BankCode", "銀行コード", 22, 26}
+	statementHeaderBankName           = field{"1", "BankName", "銀行名", 26, 41}
+	statementHeaderBranchCode         = field{"1", "BranchCode", "支店コード", 41, 44}
+	statementHeaderBranchName         = field{"1", "BranchName", "支店名", 44, 59}
+	statementHeaderDummy1             = field{"1", "Dummy1", "ダミー", 59, 62}
+	statementHeaderAccountType        = field{"1", "AccountType", "預金種目", 62, 63}
+	statementHeaderAccountNumber      = field{"1", "AccountNumber", "口座番号", 63, 73}
+	statementHeaderAccountName        = field{"1", "AccountName", "口座名", 73, 113}
+	statementHeaderOverdraft          = field{"1", "BalanceBeforeOverdraft", "貸越区分", 113, 114}
+	statementHeaderPassbookCategory   = field{"1", "PassbookCategory", "通帳・証書区分", 114, 115}
+	statementHeaderBalanceBefore      = field{"1", "BalanceBefore", "取引前残高", 115, 129}
+	statementHeaderDummy2             = field{"1", "Dummy2", "ダミー", 129, 200}
+)
+
+// 入出金取引明細 data record
+var (
+	statementDataRecordType           = field{"2", "RecordType", "データ区分", 0, 1}
+	statementDataInquiryNumber        = field{"2", "InquiryNumber", "照会番号", 1, 9}
+	statementDataAccountingDate       = field{"2", "AccountingDate", "勘定日", 9, 15}
+	statementDataTransactionDate      = field{"2", "TransactionDate", "預入・払出日", 15, 21}
+	statementDataEntryType            = field{"2", "EntryType", "入払区分", 21, 22}
+	statementDataTransactionType      = field{"2", "TransactionType", "取引区分", 22, 24}
+	statementDataAmount               = field{"2", "Amount", "取引金額", 24, 36}
+	statementDataOtherBankCheckAmount = field{"2", "OtherBankCheckAmount", "うち他店券金額", 36, 48}
+	statementDataClearingDate         = field{"2", "ClearingDate", "交換呈示日", 48, 54}
+	statementDataDishonorDate         = field{"2", "DishonorDate", "不渡返還日", 54, 60}
+	statementDataBillType             = field{"2", "BillType", "手形・小切手区分", 60, 61}
+	statementDataBillNumber           = field{"2", "BillNumber", "手形・小切手番号", 61, 68}
+	statementDataBranchCode           = field{"2", "BranchCode", "僚店番号", 68, 71}
+	statementDataSenderCode           = field{"2", "SenderCode", "振込依頼人コード", 71, 81}
+	statementDataSenderName           = field{"2", "SenderName", "振込依頼人名または契約者番号", 81, 129}
+	statementDataSenderBankName       = field{"2", "SenderBankName", "仕向銀行名", 129, 144}
+	statementDataSenderBranchName     = field{"2", "SenderBranchName", "仕向店名", 144, 159}
+	statementDataDescription          = field{"2", "Description", "摘要内容", 159, 179}
+	statementDataEdiInformation       = field{"2", "EdiInformation", "EDI情報", 179, 199}
+	statementDataDummy                = field{"2", "Dummy", "ダミー", 199, 200}
+)
+
+// 入出金取引明細 trailer record
+var (
+	statementTrailerRecordType       = field{"8", "RecordType", "データ区分", 0, 1}
+	statementTrailerDepositCount     = field{"8", "DepositCount", "入金件数", 1, 7}
+	statementTrailerDepositAmount    = field{"8", "DepositAmount", "入金額合計", 7, 20}
+	statementTrailerWithdrawalCount  = field{"8", "WithdrawalCount", "出金件数", 20, 26}
+	statementTrailerWithdrawalAmount = field{"8", "WithdrawalAmount", "出金額合計", 26, 39}
+	statementTrailerOverdraft        = field{"8", "BalanceAfterOverdraft", "貸越区分", 39, 40}
+	statementTrailerBalanceAfter     = field{"8", "BalanceAfter", "取引後残高", 40, 54}
+	statementTrailerDataCount        = field{"8", "DataCount", "データ・レコード件数", 54, 61}
+	statementTrailerDummy            = field{"8", "Dummy", "ダミー", 61, 200}
+)
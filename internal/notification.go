@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+)
+
+// notificationLayout is the layout of 振込入金通知 files
+var notificationLayout = fileLayout[types.NotificationHeader, types.NotificationData, types.NotificationTrailer]{
+	length:  types.LongRecordLength,
+	header:  parseNotificationHeader,
+	data:    parseNotificationData,
+	trailer: parseNotificationTrailer,
+	check:   checkNotificationTrailerRecord,
+}
+
+// ParseNotificationWithConfig parses a 振込入金通知 file
+func ParseNotificationWithConfig(file Reader, config Config) (types.NotificationResult, error) {
+	var result types.NotificationResult
+	encoding, diagnostics, err := parseFile(file, config, notificationLayout,
+		func(header types.NotificationHeader, data []types.NotificationData, trailer types.NotificationTrailer) {
+			result.Groups = append(result.Groups, types.NotificationGroup{Header: header, Data: data, Trailer: trailer})
+		})
+	if err != nil {
+		return types.NotificationResult{}, err
+	}
+	result.Diagnostics = diagnostics
+	result.Encoding = encoding
+
+	return result, nil
+}
+
+// checkNotificationTrailerRecord returns a *types.ParseError on the trailer field that doesn't match the data records:
+// the totals of the credits and of the cancelled credits are kept apart
+func checkNotificationTrailerRecord(line record, _ types.NotificationHeader, data []types.NotificationData, trailer types.NotificationTrailer) error {
+	var totals types.NotificationTrailer
+	for _, block := range data {
+		if block.Cancelled {
+			totals.CancelledCount++
+			totals.CancelledAmount += block.Amount
+		} else {
+			totals.TotalCount++
+			totals.TotalAmount += block.Amount
+		}
+	}
+
+	switch {
+	case totals.TotalCount != trailer.TotalCount:
+		return notificationTrailerTotalCount.error(notificationTrailerTotalCount.value(line),
+			fmt.Errorf("total count mismatch: %d data records", totals.TotalCount))
+	case totals.TotalAmount != trailer.TotalAmount:
+		return notificationTrailerTotalAmount.error(notificationTrailerTotalAmount.value(line),
+			fmt.Errorf("total amount mismatch: data records sum up to %d", totals.TotalAmount))
+	case totals.CancelledCount != trailer.CancelledCount:
+		return notificationTrailerCancelledCount.error(notificationTrailerCancelledCount.value(line),
+			fmt.Errorf("cancelled count mismatch: %d cancelled data records", totals.CancelledCount))
+	case totals.CancelledAmount != trailer.CancelledAmount:
+		return notificationTrailerCancelledAmount.error(notificationTrailerCancelledAmount.value(line),
+			fmt.Errorf("cancelled amount mismatch: cancelled data records sum up to %d", totals.CancelledAmount))
+	}
+	return nil
+}
+
+func parseNotificationHeader(line record, encoding types.Encoding) (types.NotificationHeader, error) {
+	if !notificationHeaderAccountNumber.in(line) {
+		return types.NotificationHeader{}, recordError(line, errors.New("header line too short"))
+	}
+
+	header := types.NotificationHeader{}
+
+	recordType, err := parseRecordType(line, notificationHeaderRecordType)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.RecordType = recordType
+
+	categoryCode, err := parseCategoryCode(line, notificationHeaderCategoryCode)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	if categoryCode != types.CategoryCodeNotification {
+		value := notificationHeaderCategoryCode.value(line)
+		return types.NotificationHeader{}, notificationHeaderCategoryCode.error(value, errors.New("not a 振込入金通知 file: "+value))
+	}
+	header.CategoryCode = categoryCode
+
+	encodingType, err := parseEncodingType(line, notificationHeaderEncodingType, encoding)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.EncodingType = encodingType
+
+	dates := []struct {
+		f    field
+		date *string
+	}{
+		{notificationHeaderCreatedDate, &header.CreatedDate},
+		{notificationHeaderAccountingDateFrom, &header.AccountingDateFrom},
+		{notificationHeaderAccountingDateTo, &header.AccountingDateTo},
+	}
+	for _, d := range dates {
+		if *d.date, err = parseLongDate(line, d.f); err != nil {
+			return types.NotificationHeader{}, err
+		}
+	}
+
+	bankCode, err := parseBankCode(line, notificationHeaderBankCode)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.BankCode = bankCode
+
+	bankName, err := parseText(line, notificationHeaderBankName)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.BankName = bankName
+
+	branchCode, err := parseBranchCode(line, notificationHeaderBranchCode)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.BranchCode = branchCode
+
+	branchName, err := parseText(line, notificationHeaderBranchName)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.BranchName = branchName
+
+	header.Dummy1 = notificationHeaderDummy1.value(line)
+
+	accountType, err := parseAccountType(line, notificationHeaderAccountType)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.AccountType = accountType
+
+	accountNumber, err := parseAccountNumber(line, notificationHeaderAccountNumber)
+	if err != nil {
+		return types.NotificationHeader{}, err
+	}
+	header.AccountNumber = accountNumber
+
+	// Fields below are optional
+
+	if notificationHeaderAccountName.in(line) {
+		accountName, err := parseText(line, notificationHeaderAccountName)
+		if err != nil {
+			return types.NotificationHeader{}, err
+		}
+		header.AccountName = accountName
+	}
+
+	if notificationHeaderDummy2.in(line) {
+		header.Dummy2 = notificationHeaderDummy2.value(line)
+	}
+
+	return header, nil
+}
+
+func parseNotificationData(line record) (types.NotificationData, error) {
+	if !notificationDataCancelled.in(line) {
+		return types.NotificationData{}, recordError(line, errors.New("data line is too short"))
+	}
+
+	data := types.NotificationData{}
+
+	recordType, err := parseRecordType(line, notificationDataRecordType)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.RecordType = recordType
+
+	inquiryNumber, err := parseOptionalCode(line, notificationDataInquiryNumber)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.InquiryNumber = inquiryNumber
+
+	accountingDate, err := parseLongDate(line, notificationDataAccountingDate)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.AccountingDate = accountingDate
+
+	valueDate, err := parseLongDate(line, notificationDataValueDate)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.ValueDate = valueDate
+
+	amount, err := parseAmount(line, notificationDataAmount)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.Amount = amount
+
+	otherBankCheckAmount, err := parseAmount(line, notificationDataOtherBankCheckAmount)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.OtherBankCheckAmount = otherBankCheckAmount
+
+	senderCode, err := parseOptionalCode(line, notificationDataSenderCode) // optional
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.SenderCode = senderCode
+
+	senderName, err := parseText(line, notificationDataSenderName)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.SenderName = senderName
+
+	senderBankName, err := parseText(line, notificationDataSenderBankName)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.SenderBankName = senderBankName
+
+	senderBranchName, err := parseText(line, notificationDataSenderBranchName)
+	if err != nil {
+		return types.NotificationData{}, err
+	}
+	data.SenderBranchName = senderBranchName
+
+	switch cancelled := notificationDataCancelled.value(line); cancelled {
+	case "1":
+		data.Cancelled = true
+	case " ", "0":
+	default:
+		return types.NotificationData{}, notificationDataCancelled.error(cancelled, errors.New("invalid cancel category: "+cancelled))
+	}
+
+	// Fields below are optional
+
+	if notificationDataEdiInformation.in(line) {
+		ediInformation, err := parseText(line, notificationDataEdiInformation)
+		if err != nil {
+			return types.NotificationData{}, err
+		}
+		data.EdiInformation = ediInformation
+	}
+
+	if notificationDataDummy.in(line) {
+		data.Dummy = notificationDataDummy.value(line)
+	}
+
+	return data, nil
+}
+
+func parseNotificationTrailer(line record) (types.NotificationTrailer, error) {
+	if !notificationTrailerCancelledAmount.in(line) {
+		return types.NotificationTrailer{}, recordError(line, errors.New("trailer line too short"))
+	}
+
+	trailer := types.NotificationTrailer{}
+
+	recordType, err := parseRecordType(line, notificationTrailerRecordType)
+	if err != nil {
+		return types.NotificationTrailer{}, err
+	}
+	trailer.RecordType = recordType
+
+	if trailer.TotalCount, err = parseCount(line, notificationTrailerTotalCount); err != nil {
+		return types.NotificationTrailer{}, err
+	}
+	if trailer.TotalAmount, err = parseAmount(line, notificationTrailerTotalAmount); err != nil {
+		return types.NotificationTrailer{}, err
+	}
+	if trailer.CancelledCount, err = parseCount(line, notificationTrailerCancelledCount); err != nil {
+		return types.NotificationTrailer{}, err
+	}
+	if trailer.CancelledAmount, err = parseAmount(line, notificationTrailerCancelledAmount); err != nil {
+		return types.NotificationTrailer{}, err
+	}
+
+	if notificationTrailerDummy.in(line) {
+		trailer.Dummy = notificationTrailerDummy.value(line)
+	}
+
+	return trailer, nil
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Kyash/zengin-go/types"
-	"log"
-	"strconv"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
 )
 
 type ParseState int
@@ -18,231 +20,436 @@ const (
 	StateEnd
 )
 
+// Config holds the parser options. The zero value stops at the first error.
+type Config struct {
+	// Lenient keeps parsing after an invalid record and returns every error as a diagnostic.
+	// Invalid data records are left out of the transfers of their header group, and so is a whole group
+	// with an invalid header or trailer record. Trailer totals that don't match the data records are only reported,
+	// including the mismatch caused by the data records left out.
+	Lenient bool
+	// Encoding skips detection and reads the file in this encoding.
+	// It can be types.EncodingShiftJIS, types.EncodingUTF8 or an encoding returned by RegisterEncoding.
+	Encoding types.Encoding
+	// RecordFormat selects records separated by line breaks or of fixed length, detected by default.
+	RecordFormat types.RecordFormat
+	// LineEnding requires or forbids CRLF between records.
+	LineEnding types.LineEnding
+	// StrictRecords reports lines that are not header, data, trailer or end records instead of skipping them.
+	StrictRecords bool
+	// Logger receives debug messages about detection and skipped lines, and a warning for every diagnostic.
+	// Nothing is logged if it is nil.
+	Logger *slog.Logger
+	// BankDirectory rejects unknown bank and branch codes, fills in blank bank and branch names
+	// and reports names that don't match their code as warnings.
+	BankDirectory types.BankDirectory
+	// Clock returns the reference date from which the year of the transfer dates is inferred.
+	// Transfer dates are resolved if it is set.
+	Clock func() time.Time
+	// Calendar rejects transfer dates on which banks are closed. It needs Clock, otherwise parsing returns
+	// an error wrapping types.ErrNoReferenceDate.
+	Calendar types.Calendar
+}
+
+func (c Config) debug(msg string, args ...any) {
+	if c.Logger != nil {
+		c.Logger.Debug(msg, args...)
+	}
+}
+
+func (c Config) warn(msg string, args ...any) {
+	if c.Logger != nil {
+		c.Logger.Warn(msg, args...)
+	}
+}
+
 func Parse(file Reader) ([]types.Transfer, error) {
 
-	scanner, encoding, err := guessEncoding(file)
+	result, err := ParseWithConfig(file, Config{})
 	if err != nil {
 		return nil, err
 	}
+	if len(result.Transfers) == 0 {
+		return nil, types.ErrNoTransfers
+	}
 
-	var transfers []types.Transfer
-	var header types.Header
-	var data []types.Data
-	var trailer types.Trailer
+	return result.Transfers, nil
+}
 
-	var state = StateUnknown
+// ParseWithConfig parses the file and returns its transfers, with the errors found on the way in lenient mode
+func ParseWithConfig(file Reader, config Config) (types.ParseResult, error) {
+	parser, err := newGroupParser(file, config, true)
+	if err != nil {
+		return types.ParseResult{}, err
+	}
 
-	for scanner.Scan() {
-		line := []rune(scanner.Text())
-		if len(line) == 0 {
-			continue
+	var result types.ParseResult
+	for {
+		group, err := parser.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return types.ParseResult{}, err
 		}
 
-		// Remove BOM if exists
-		if len(line) >= 1 && line[0] == '\ufeff' {
-			line = line[1:]
+		transfers, err := createTransfers(group.Header, group.Data, group.Trailer)
+		if err != nil {
+			return types.ParseResult{}, err
 		}
+		result.Transfers = append(result.Transfers, transfers...)
+	}
+	result.Diagnostics = parser.diagnostics
+	result.Warnings = parser.warnings
+	result.Encoding = parser.records.encoding
+
+	return result, nil
+}
+
+// ParseGroups parses the file and returns every header record with its data and trailer records as parsed,
+// without checking the trailer totals
+func ParseGroups(file Reader) ([]types.Group, error) {
+	f, err := ParseFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return f.Groups, nil
+}
 
-		var err error
-		switch {
-		case types.IsHeader(line):
-			if state == StateData || state == StateEnd {
-				return nil, errors.New("found record with missing trailer")
+// ParseFile parses the file like ParseGroups and returns its header groups together with its end record
+// and line endings, so that it can be written back as it was
+func ParseFile(file Reader) (types.File, error) {
+
+	parser, err := newGroupParser(file, Config{}, false)
+	if err != nil {
+		return types.File{}, err
+	}
+
+	var f types.File
+	for {
+		group, err := parser.next()
+		if err == io.EOF {
+			f.End = parser.end
+			f.EndLineEnding = parser.records.lineEnding
+			return f, nil
+		}
+		if err != nil {
+			return types.File{}, err
+		}
+		if len(f.Groups) == 0 {
+			f.LineEnding = parser.records.lineEnding // of the first trailer record
+		}
+		f.Groups = append(f.Groups, group)
+	}
+}
+
+// reporter reads the records of a file and stops at the first error, or keeps every error in lenient mode
+type reporter struct {
+	records     *recordScanner
+	config      Config
+	diagnostics []*types.ParseError
+	warnings    []*types.ParseError
+}
+
+// groupParser reads a file one header group at a time
+type groupParser struct {
+	reporter
+	checkTotals bool
+
+	header types.Header
+	data   []types.Data
+	valid  bool // whether the header record of the current group was parsed
+	end    types.End
+}
+
+func newGroupParser(file Reader, config Config, checkTotals bool) (*groupParser, error) {
+	if err := checkCalendar(config); err != nil {
+		return nil, err
+	}
+	records, err := newRecordScanner(file, config, types.RecordLength)
+	if err != nil {
+		return nil, err
+	}
+	return &groupParser{reporter: reporter{records: records, config: config}, checkTotals: checkTotals}, nil
+}
+
+// next returns the next header group, or io.EOF after the end record.
+// In lenient mode, invalid data records and groups with an invalid header or trailer record are skipped
+// and the errors are kept in diagnostics.
+func (p *groupParser) next() (types.Group, error) {
+	for {
+		state, line, err := p.records.next()
+		if err == io.EOF {
+			return types.Group{}, err
+		}
+		if err := p.report(err); err != nil {
+			return types.Group{}, err
+		}
+
+		switch state {
+		case StateHeader:
+			header, err := parseHeader(line, p.records.encoding)
+			if err == nil {
+				err = p.checkBank(line, headerSenderBankCode, headerSenderBankName, headerSenderBranchCode, headerSenderBranchName,
+					header.SenderBankCode, header.SenderBranchCode, &header.SenderBankName, &header.SenderBranchName)
 			}
-			header, err = parseHeader(line, encoding)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing header: %w", err)
+			if err == nil {
+				err = p.checkTransferDate(line, &header)
 			}
-			state = StateHeader
-
-		case types.IsData(line):
-			if state != StateHeader && state != StateData {
-				return nil, errors.New("data record found before header")
+			if err := p.report(err); err != nil {
+				return types.Group{}, err
+			}
+			p.header = header
+			p.data = nil
+			p.valid = err == nil
+
+		case StateData:
+			data, err := parseData(line, p.header.CategoryCode)
+			if err == nil {
+				err = p.checkBank(line, dataRecipientBankCode, dataRecipientBankName, dataRecipientBranchCode, dataRecipientBranchName,
+					data.RecipientBankCode, data.RecipientBranchCode, &data.RecipientBankName, &data.RecipientBranchName)
+			}
+			if err := p.report(err); err != nil {
+				return types.Group{}, err
 			}
-			dataRecord, err := parseData(line)
 			if err != nil {
-				return nil, fmt.Errorf("error parsing data record: %w", err)
+				continue // left out of the group, the trailer check reports the totals that don't match
 			}
-			data = append(data, dataRecord)
-			state = StateData
+			p.data = append(p.data, data)
 
-		case types.IsTrailer(line):
-			if state != StateData && state != StateHeader {
-				return nil, errors.New("trailer record found before header")
+		case StateTrailer:
+			trailer, err := parseTrailer(line)
+			if err := p.report(err); err != nil {
+				return types.Group{}, err
 			}
-			trailer, err = parseTrailer(line)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing trailer record: %w", err)
+			group := types.Group{Header: p.header, Data: p.data, Trailer: trailer}
+			p.data = []types.Data{} // reset data for new header
+			if !p.valid || err != nil {
+				continue
 			}
-			// Create transfers from previous data before starting a new header
-			newTransfers, err := createTransfers(header, data, trailer)
-			if err != nil {
-				return nil, err
+			if p.checkTotals {
+				err := checkTrailerRecord(line, len(group.Data), sumAmount(group.Data), trailer)
+				if err := p.report(err); err != nil {
+					return types.Group{}, err
+				}
 			}
-			transfers = append(transfers, newTransfers...)
-			data = []types.Data{} // reset data for new header
-			state = StateTrailer
+			return group, nil
 
-		case types.IsEndRecord(line):
-			if state != StateTrailer {
-				return nil, errors.New("end record found before trailer")
+		case StateEnd:
+			end, err := parseEnd(line)
+			if err := p.report(err); err != nil {
+				return types.Group{}, err
 			}
-			state = StateEnd
-			break
-
-		default:
-			// Some programs seem to put invisible characters, just ignore them
-			continue
+			p.end = end
 		}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// report returns err with the index of the current record set, or keeps it as a diagnostic in lenient mode
+func (p *reporter) report(err error) error {
+	if err == nil {
+		return nil
 	}
-	if state != StateEnd {
-		return nil, errors.New("unexpected end of file")
+	err = p.records.wrap(err)
+	var parseError *types.ParseError
+	if p.config.Lenient && errors.As(err, &parseError) {
+		p.config.warn("invalid record", "error", parseError)
+		p.diagnostics = append(p.diagnostics, parseError)
+		return nil
 	}
-	if len(transfers) == 0 {
-		log.Println("No transfers found in file")
-		return nil, nil
+	return err
+}
+
+// checkTrailerRecord returns a *types.ParseError on the trailer field that doesn't match the data records
+func checkTrailerRecord(line record, count int, amount uint64, trailer types.Trailer) error {
+	if count != trailer.TotalCount {
+		return trailerTotalCount.error(trailerTotalCount.value(line),
+			fmt.Errorf("total count mismatch: %d data records", count))
+	}
+	if amount != trailer.TotalAmount {
+		return trailerTotalAmount.error(trailerTotalAmount.value(line),
+			fmt.Errorf("total amount mismatch: data records sum up to %d", amount))
 	}
+	return nil
+}
 
-	return transfers, nil
+// recordError returns a *types.ParseError about the whole record
+func recordError(line record, err error) *types.ParseError {
+	return &types.ParseError{RecordType: line.slice(0, 1), Value: line.String(), Err: err}
 }
 
-func parseHeader(line []rune, encoding types.Encoding) (types.Header, error) {
-	if len(line) < types.MinHeaderLength { // Ensure line has enough characters
-		return types.Header{}, errors.New("header line too short")
+func parseHeader(line record, encoding types.Encoding) (types.Header, error) {
+	if line.len() < types.MinHeaderLength { // Ensure line has enough bytes
+		return types.Header{}, recordError(line, errors.New("header line too short"))
 	}
 
 	header := types.Header{}
 
-	recordType := string(line[0:1])
-	if recordType != "1" {
-		return types.Header{}, errors.New("header record type is not 1")
+	recordType, err := parseRecordType(line, headerRecordType)
+	if err != nil {
+		return types.Header{}, err
 	}
 	header.RecordType = recordType
 
-	categoryCode, err := parseCategoryCode(string(line[1:3]))
+	categoryCode, err := parseCategoryCode(line, headerCategoryCode)
 	if err != nil {
 		return types.Header{}, err
 	}
+	switch categoryCode {
+	case types.CategoryCodeDebit:
+		return types.Header{}, headerCategoryCode.error("91", errors.New("口座振替 file, parse it with ParseDebit"))
+	case types.CategoryCodeNotification:
+		return types.Header{}, headerCategoryCode.error("01", errors.New("振込入金通知 file, parse it with ParseNotification"))
+	case types.CategoryCodeStatement:
+		return types.Header{}, headerCategoryCode.error("03", errors.New("入出金取引明細 file, parse it with ParseStatement"))
+	}
 	header.CategoryCode = categoryCode
+	header.RawCategoryCode = headerCategoryCode.value(line)
 
-	encodingType := string(line[3:4])
-	if encoding != types.EncodingShiftJIS && encodingType == "0" {
-		return types.Header{}, errors.New("unsupported encoding type: " + encodingType)
+	encodingType, err := parseEncodingType(line, headerEncodingType, encoding)
+	if err != nil {
+		return types.Header{}, err
 	}
 	header.EncodingType = encodingType
 
-	senderCode, err := parseSenderCode(string(line[4:14]))
+	senderCode, err := parseSenderCode(line, headerSenderCode)
+	senderNameField := headerSenderName
+	if header.IsPayroll() {
+		senderCode, err = parseCompanyCode(line, headerCompanyCode)
+		senderNameField = headerCompanyName
+	}
 	if err != nil {
 		return types.Header{}, err
 	}
 	header.SenderCode = senderCode
 
-	header.SenderName = string(line[14:54])
+	senderName, err := parseText(line, senderNameField)
+	if err != nil {
+		return types.Header{}, err
+	}
+	header.SenderName = senderName
 
-	date, err := parseDate(string(line[54:58]))
+	date, err := parseDate(line, headerTransferDate)
 	if err != nil {
-		return types.Header{}, fmt.Errorf("invalid transfer date: %w", err)
+		return types.Header{}, err
 	}
 	header.TransferDate = date
 
-	bankCode, err := parseBankCode(string(line[58:62]))
+	bankCode, err := parseBankCode(line, headerSenderBankCode)
 	if err != nil {
 		return types.Header{}, err
 	}
 	header.SenderBankCode = bankCode
 
-	header.SenderBankName = string(line[62:77]) // optional
+	bankName, err := parseText(line, headerSenderBankName) // optional
+	if err != nil {
+		return types.Header{}, err
+	}
+	header.SenderBankName = bankName
 
-	branchCode, err := parseBranchCode(string(line[77:80]))
+	branchCode, err := parseBranchCode(line, headerSenderBranchCode)
 	if err != nil {
 		return types.Header{}, err
 	}
-	header.SenderBankCode = branchCode
+	header.SenderBranchCode = branchCode
 
 	// Fields below are optional
 
-	if len(line) >= 95 {
-		header.SenderBranchName = string(line[80:95])
+	if headerSenderBranchName.in(line) {
+		branchName, err := parseText(line, headerSenderBranchName)
+		if err != nil {
+			return types.Header{}, err
+		}
+		header.SenderBranchName = branchName
 	}
 
-	if len(line) >= 96 {
-		accountType, err := parseAccountType(string(line[95:96]))
+	if headerSenderAccountType.in(line) && strings.TrimSpace(headerSenderAccountType.value(line)) != "" {
+		accountType, err := parseAccountType(line, headerSenderAccountType)
 		if err != nil {
 			return types.Header{}, err
 		}
 		header.SenderAccountType = accountType
 	}
 
-	if len(line) >= 103 {
-		header.SenderAccountNumber = string(line[96:103])
+	if headerSenderAccountNumber.in(line) {
+		header.SenderAccountNumber = headerSenderAccountNumber.value(line)
+	}
+
+	if headerDummy.in(line) {
+		header.Dummy = headerDummy.value(line)
 	}
 
 	return header, nil
 }
 
-func parseData(line []rune) (types.Data, error) {
-	if len(line) < types.MinDataLength { // Ensure the line is of expected length
-		return types.Data{}, errors.New("data line is too short")
+// parseData parses a data record of a file of the given category, following the rules of 給与・賞与振込 for them
+func parseData(line record, categoryCode types.CategoryCode) (types.Data, error) {
+	if line.len() < types.MinDataLength { // Ensure the line is of expected length
+		return types.Data{}, recordError(line, errors.New("data line is too short"))
 	}
 
 	data := types.Data{}
 
-	recordType := string(line[0:1])
-	if recordType != "2" {
-		return types.Data{}, errors.New("data record type is not 2")
+	recordType, err := parseRecordType(line, dataRecordType)
+	if err != nil {
+		return types.Data{}, err
 	}
 	data.RecordType = recordType
 
-	bankCode, err := parseBankCode(string(line[1:5]))
+	bankCode, err := parseBankCode(line, dataRecipientBankCode)
 	if err != nil {
 		return types.Data{}, err
 	}
 	data.RecipientBankCode = bankCode
 
-	data.RecipientBankName = string(line[5:20]) // optional
+	bankName, err := parseText(line, dataRecipientBankName) // optional
+	if err != nil {
+		return types.Data{}, err
+	}
+	data.RecipientBankName = bankName
 
-	branchCode, err := parseBranchCode(string(line[20:23]))
+	branchCode, err := parseBranchCode(line, dataRecipientBranchCode)
 	if err != nil {
 		return types.Data{}, err
 	}
 	data.RecipientBranchCode = branchCode
 
-	data.RecipientBranchName = string(line[23:38]) // optional
+	branchName, err := parseText(line, dataRecipientBranchName) // optional
+	if err != nil {
+		return types.Data{}, err
+	}
+	data.RecipientBranchName = branchName
 
-	exchangeOfficeCode := string(line[38:42]) // optional
-	if exchangeOfficeCode != "    " {
-		if _, err := strconv.Atoi(exchangeOfficeCode); err != nil {
-			return types.Data{}, errors.New("invalid exchange office code: contains non-numeric characters")
-		}
+	exchangeOfficeCode, err := parseOptionalCode(line, dataExchangeOfficeCode) // optional
+	if err != nil {
+		return types.Data{}, err
 	}
 	data.ExchangeOfficeCode = exchangeOfficeCode
 
-	accountType, err := parseAccountType(string(line[42:43]))
+	accountType, err := parseAccountType(line, dataRecipientAccountType)
 	if err != nil {
 		return types.Data{}, err
 	}
 	data.RecipientAccountType = accountType
 
-	accountNumber := string(line[43:50])
-	if _, err := strconv.Atoi(accountNumber); err != nil {
-		return types.Data{}, errors.New("invalid account number: contains non-numeric characters")
+	accountNumber, err := parseAccountNumber(line, dataRecipientAccountNumber)
+	if err != nil {
+		return types.Data{}, err
 	}
 	data.RecipientAccountNumber = accountNumber
 
-	data.RecipientName = string(line[50:80])
+	recipientName, err := parseText(line, dataRecipientName)
+	if err != nil {
+		return types.Data{}, err
+	}
+	data.RecipientName = recipientName
 
-	// Parse transfer amount as integer
-	amount, err := strconv.ParseUint(string(line[80:90]), 10, 64)
+	amount, err := parseAmount(line, dataAmount)
 	if err != nil {
-		return types.Data{}, fmt.Errorf("invalid transfer amount: %v", err)
+		return types.Data{}, err
 	}
 	data.Amount = amount
 
-	newCode, err := parseNewCode(string(line[90:91])) // unused
+	newCode, err := parseNewCode(line, dataNewCode) // unused
 	if err != nil {
 		return types.Data{}, err
 	}
@@ -250,54 +457,97 @@ func parseData(line []rune) (types.Data, error) {
 
 	// Fields below are optional
 
-	if len(line) >= 111 {
-		data.Extra = string(line[91:111])
+	if dataExtra.in(line) {
+		extra, err := parseText(line, dataExtra)
+		if err != nil {
+			return types.Data{}, err
+		}
+		data.Extra = extra
 	}
 
-	if len(line) >= 112 {
-		data.TransferCategory = string(line[111:112]) // unused
-		if _, err := strconv.Atoi(data.TransferCategory); err != nil {
-			return types.Data{}, errors.New("invalid transfer category: contains non-numeric characters")
+	if dataTransferCategory.in(line) {
+		transferCategory, err := parseOptionalCode(line, dataTransferCategory) // unused
+		if err != nil {
+			return types.Data{}, err
 		}
+		data.TransferCategory = transferCategory
+	}
+
+	if dataEdiPresent.in(line) {
+		data.EdiPresent = dataEdiPresent.value(line) == "Y"
+	}
+
+	if dataDummy.in(line) {
+		data.Dummy = dataDummy.value(line)
 	}
 
-	if len(line) >= 113 {
-		ediPresent := string(line[112:113])
-		if ediPresent == "Y" {
-			data.EdiPresent = true
-		} else {
-			data.EdiPresent = false
+	if categoryCode == types.CategoryCodePayment || categoryCode == types.CategoryCodeBonus {
+		if err := checkPayrollData(line, data); err != nil {
+			return types.Data{}, err
 		}
 	}
 
 	return data, nil
 }
 
-func parseTrailer(line []rune) (types.Trailer, error) {
-	if len(line) < types.MinTrailerLength { // Ensure the line is of expected length
-		return types.Trailer{}, errors.New("trailer line too short")
-	}
+// checkPayrollData returns a *types.ParseError on a field of a 給与・賞与振込 data record that is only allowed in 総合振込
+func checkPayrollData(line record, data types.Data) error {
+	switch {
+	case data.RecipientAccountType == types.AccountTypeOther:
+		return dataRecipientAccountType.error(dataRecipientAccountType.value(line),
+			errors.New("account type must be 1, 2 or 4 in 給与・賞与振込"))
+	case strings.TrimSpace(data.TransferCategory) != "":
+		return dataTransferCategory.error(data.TransferCategory, errors.New("transfer category must be blank in 給与・賞与振込"))
+	case data.EdiPresent:
+		return dataEdiPresent.error("Y", errors.New("EDI information is not allowed in 給与・賞与振込"))
+	}
+	return nil
+}
 
-	trailer := types.Trailer{
-		RecordType: string(line[0:1]),
+func parseTrailer(line record) (types.Trailer, error) {
+	if line.len() < types.MinTrailerLength { // Ensure the line is of expected length
+		return types.Trailer{}, recordError(line, errors.New("trailer line too short"))
 	}
-	if trailer.RecordType != "8" {
-		return types.Trailer{}, errors.New("invalid record type for trailer")
+
+	trailer := types.Trailer{}
+
+	recordType, err := parseRecordType(line, trailerRecordType)
+	if err != nil {
+		return types.Trailer{}, err
 	}
+	trailer.RecordType = recordType
 
-	// Parse TotalCount as integer
-	totalCount, err := strconv.Atoi(string(line[1:7]))
+	totalCount, err := parseCount(line, trailerTotalCount)
 	if err != nil {
-		return types.Trailer{}, fmt.Errorf("invalid total count: %v", err)
+		return types.Trailer{}, err
 	}
 	trailer.TotalCount = totalCount
 
-	// Parse TotalAmount as integer
-	totalAmount, err := strconv.ParseUint(string(line[7:19]), 10, 64)
+	totalAmount, err := parseAmount(line, trailerTotalAmount)
 	if err != nil {
-		return types.Trailer{}, fmt.Errorf("invalid total amount: %v", err)
+		return types.Trailer{}, err
 	}
 	trailer.TotalAmount = totalAmount
 
+	if trailerDummy.in(line) {
+		trailer.Dummy = trailerDummy.value(line)
+	}
+
 	return trailer, nil
 }
+
+func parseEnd(line record) (types.End, error) {
+	end := types.End{}
+
+	recordType, err := parseRecordType(line, endRecordType)
+	if err != nil {
+		return types.End{}, err
+	}
+	end.RecordType = recordType
+
+	if endDummy.in(line) {
+		end.Dummy = endDummy.value(line)
+	}
+
+	return end, nil
+}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"golang.org/x/text/encoding"
+	"io"
+	"strings"
+)
+
+// recordScanner reads the records of a file one at a time and checks that they come in the order
+// header → data* → trailer, repeated for every header group, followed by an end record
+type recordScanner struct {
+	scanner  *bufio.Scanner
+	decoder  *encoding.Decoder // nil for UTF-8
+	config   Config
+	encoding types.Encoding
+	state    ParseState
+	record   int // index of the current record, counting every line
+	// line ending of the current record, empty for fixed-length records and the last line of a file without one
+	lineEnding string
+	endings    []byte // bytes of the line endings in the encoding of the file
+	started    bool   // whether the scanner has read ahead of the current record
+	ahead      bool   // whether there is a record after the current one
+	current    []byte // raw bytes of the current record, as the scanner has moved on to the next one
+}
+
+// newRecordScanner returns a recordScanner for a file whose fixed-length records are length bytes long,
+// or length characters in UTF-8
+func newRecordScanner(file Reader, config Config, length int) (*recordScanner, error) {
+	reader := bufio.NewReader(file)
+	peekBytes, err := reader.Peek(1024)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+	}
+
+	s := &recordScanner{config: config, encoding: config.Encoding, state: StateUnknown}
+	if s.encoding == types.EncodingUndefined {
+		var name string
+		s.encoding, name = guessEncoding(peekBytes)
+		config.debug("detected encoding", "encoding", s.encoding, "charset", name)
+	}
+	if s.encoding == types.EncodingUTF8 {
+		// Drop the BOM so that it doesn't shift fixed-length records
+		if bytes.HasPrefix(peekBytes, []byte("\ufeff")) {
+			_, _ = reader.Discard(len("\ufeff"))
+		}
+	}
+	var e encoding.Encoding
+	if s.encoding != types.EncodingUTF8 {
+		if e, err = lookupEncoding(s.encoding); err != nil {
+			return nil, err
+		}
+		s.decoder = e.NewDecoder()
+	}
+	breaks, endings := lineBreaks(e)
+	s.endings = endings
+
+	format := config.RecordFormat
+	if format == types.RecordFormatAuto {
+		format = guessRecordFormat(peekBytes, length, breaks)
+		config.debug("detected record format", "format", format)
+	}
+
+	// Records are split before decoding, so that fixed-length records can be cut by bytes
+	s.scanner = bufio.NewScanner(reader)
+	switch {
+	case format == types.RecordFormatLines:
+		s.scanner.Split(scanRecords(breaks))
+	case s.encoding == types.EncodingUTF8:
+		s.scanner.Split(scanFixedRunes(length))
+	default:
+		s.scanner.Split(scanFixedBytes(length))
+	}
+
+	return s, nil
+}
+
+// next returns the next record with the state it moves to: StateHeader, StateData, StateTrailer or StateEnd.
+// It returns io.EOF once the file ends after the end record.
+// A header or end record out of order is returned with its state together with the error,
+// so that a lenient parser can go on from it, and so is a record with the wrong line ending.
+// Other records out of order are skipped.
+func (s *recordScanner) next() (ParseState, record, error) {
+	for s.scan() {
+		s.record++
+		raw := s.current
+		trimmed := raw
+		for len(trimmed) > 0 && bytes.IndexByte(s.endings, trimmed[len(trimmed)-1]) >= 0 {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		rec, err := newRecord(trimmed, s.decoder)
+		if err != nil {
+			return StateUnknown, record{}, &types.ParseError{Record: s.record, Value: string(trimmed), Err: err}
+		}
+		ending, err := newRecord(raw[len(trimmed):], s.decoder)
+		if err != nil {
+			return StateUnknown, record{}, &types.ParseError{Record: s.record, Value: string(trimmed), Err: err}
+		}
+		lineEnding := string(ending.runes)
+		line := rec.runes
+		if len(line) == 0 {
+			continue
+		}
+
+		// Remove BOM if exists
+		if len(line) >= 1 && line[0] == '\ufeff' {
+			rec.runes = line[1:]
+			line = rec.runes
+		}
+
+		switch {
+		case types.IsHeader(line):
+			if s.state == StateData || s.state == StateEnd {
+				s.state = StateHeader
+				return s.state, rec, s.error(line, "found record with missing trailer")
+			}
+			s.state = StateHeader
+
+		case types.IsData(line):
+			if s.state != StateHeader && s.state != StateData {
+				return StateUnknown, record{}, s.error(line, "data record found before header")
+			}
+			s.state = StateData
+
+		case types.IsTrailer(line):
+			if s.state != StateData && s.state != StateHeader {
+				return StateUnknown, record{}, s.error(line, "trailer record found before header")
+			}
+			s.state = StateTrailer
+
+		case types.IsEndRecord(line):
+			if s.state != StateTrailer {
+				s.state = StateEnd
+				return s.state, rec, s.error(line, "end record found before trailer")
+			}
+			s.state = StateEnd
+
+		default:
+			if s.config.StrictRecords {
+				return StateUnknown, record{}, &types.ParseError{
+					Record: s.record,
+					Value:  string(line),
+					Err:    errors.New("unknown record type"),
+				}
+			}
+			// Some programs seem to put invisible characters, just ignore them
+			s.config.debug("skipped line that is not a record", "record", s.record, "value", string(line))
+			continue
+		}
+		s.lineEnding = lineEnding
+		return s.state, rec, s.checkLineEnding(line, lineEnding)
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return StateUnknown, record{}, fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+	}
+	if s.state != StateEnd {
+		return StateUnknown, record{}, &types.ParseError{Record: s.record + 1, Err: errors.New("unexpected end of file")}
+	}
+	return StateUnknown, record{}, io.EOF
+}
+
+// scan reads the next record into the scanner like bufio.Scanner.Scan, reading one record ahead
+// so that the last record of the file is known
+func (s *recordScanner) scan() bool {
+	if !s.started {
+		s.started = true
+		s.ahead = s.scanner.Scan()
+	}
+	if !s.ahead {
+		return false
+	}
+	s.current = append(s.current[:0], s.scanner.Bytes()...)
+	s.ahead = s.scanner.Scan()
+	return true
+}
+
+// checkLineEnding checks the line ending of a record against the policy of the config.
+// Only the last record of the file may have no line ending with LineEndingCRLF.
+func (s *recordScanner) checkLineEnding(line []rune, lineEnding string) error {
+	switch {
+	case s.config.LineEnding == types.LineEndingCRLF && lineEnding != "\r\n" && (lineEnding != "" || s.ahead):
+		return s.error(line, "record must end with CRLF")
+	case s.config.LineEnding == types.LineEndingLF && strings.Contains(lineEnding, "\r"):
+		return s.error(line, "record must not end with CRLF")
+	}
+	return nil
+}
+
+func (s *recordScanner) error(line []rune, message string) error {
+	return &types.ParseError{Record: s.record, RecordType: string(line[0:1]), Err: errors.New(message)}
+}
+
+// wrap sets the index of the current record in a *types.ParseError returned while parsing it
+func (s *recordScanner) wrap(err error) error {
+	var parseError *types.ParseError
+	if errors.As(err, &parseError) && parseError.Record == 0 {
+		parseError.Record = s.record
+	}
+	return err
+}
@@ -0,0 +1,328 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+)
+
+// statementLayout is the layout of 入出金取引明細 files
+var statementLayout = fileLayout[types.StatementHeader, types.StatementData, types.StatementTrailer]{
+	length:  types.LongRecordLength,
+	header:  parseStatementHeader,
+	data:    parseStatementData,
+	trailer: parseStatementTrailer,
+	check:   checkStatementTrailerRecord,
+}
+
+// ParseStatementWithConfig parses a 入出金取引明細 file, with a header group for every account
+func ParseStatementWithConfig(file Reader, config Config) (types.StatementResult, error) {
+	var result types.StatementResult
+	encoding, diagnostics, err := parseFile(file, config, statementLayout,
+		func(header types.StatementHeader, data []types.StatementData, trailer types.StatementTrailer) {
+			result.Groups = append(result.Groups, types.StatementGroup{Header: header, Data: data, Trailer: trailer})
+		})
+	if err != nil {
+		return types.StatementResult{}, err
+	}
+	result.Diagnostics = diagnostics
+	result.Encoding = encoding
+
+	return result, nil
+}
+
+// checkStatementTrailerRecord returns a *types.ParseError on the trailer field that doesn't match the data records:
+// the deposit and withdrawal totals, the number of records and the balance after the transactions
+func checkStatementTrailerRecord(line record, header types.StatementHeader, data []types.StatementData, trailer types.StatementTrailer) error {
+	var totals types.StatementTrailer
+	for _, block := range data {
+		if block.EntryType == types.EntryTypeDeposit {
+			totals.DepositCount++
+			totals.DepositAmount += block.Amount
+		} else {
+			totals.WithdrawalCount++
+			totals.WithdrawalAmount += block.Amount
+		}
+	}
+	balance := header.BalanceBefore + int64(totals.DepositAmount) - int64(totals.WithdrawalAmount)
+
+	switch {
+	case totals.DepositCount != trailer.DepositCount:
+		return statementTrailerDepositCount.error(statementTrailerDepositCount.value(line),
+			fmt.Errorf("deposit count mismatch: %d deposit records", totals.DepositCount))
+	case totals.DepositAmount != trailer.DepositAmount:
+		return statementTrailerDepositAmount.error(statementTrailerDepositAmount.value(line),
+			fmt.Errorf("deposit amount mismatch: deposit records sum up to %d", totals.DepositAmount))
+	case totals.WithdrawalCount != trailer.WithdrawalCount:
+		return statementTrailerWithdrawalCount.error(statementTrailerWithdrawalCount.value(line),
+			fmt.Errorf("withdrawal count mismatch: %d withdrawal records", totals.WithdrawalCount))
+	case totals.WithdrawalAmount != trailer.WithdrawalAmount:
+		return statementTrailerWithdrawalAmount.error(statementTrailerWithdrawalAmount.value(line),
+			fmt.Errorf("withdrawal amount mismatch: withdrawal records sum up to %d", totals.WithdrawalAmount))
+	case len(data) != trailer.DataCount:
+		return statementTrailerDataCount.error(statementTrailerDataCount.value(line),
+			fmt.Errorf("data count mismatch: %d data records", len(data)))
+	case balance != trailer.BalanceAfter:
+		return statementTrailerBalanceAfter.error(statementTrailerBalanceAfter.value(line),
+			fmt.Errorf("balance mismatch: balance before the transactions and the totals make %d", balance))
+	}
+	return nil
+}
+
+func parseStatementHeader(line record, encoding types.Encoding) (types.StatementHeader, error) {
+	if !statementHeaderBalanceBefore.in(line) {
+		return types.StatementHeader{}, recordError(line, errors.New("header line too short"))
+	}
+
+	header := types.StatementHeader{}
+
+	recordType, err := parseRecordType(line, statementHeaderRecordType)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.RecordType = recordType
+
+	categoryCode, err := parseCategoryCode(line, statementHeaderCategoryCode)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	if categoryCode != types.CategoryCodeStatement {
+		value := statementHeaderCategoryCode.value(line)
+		return types.StatementHeader{}, statementHeaderCategoryCode.error(value, errors.New("not a 入出金取引明細 file: "+value))
+	}
+	header.CategoryCode = categoryCode
+
+	encodingType, err := parseEncodingType(line, statementHeaderEncodingType, encoding)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.EncodingType = encodingType
+
+	dates := []struct {
+		f    field
+		date *string
+	}{
+		{statementHeaderCreatedDate, &header.CreatedDate},
+		{statementHeaderAccountingDateFrom, &header.AccountingDateFrom},
+		{statementHeaderAccountingDateTo, &header.AccountingDateTo},
+	}
+	for _, d := range dates {
+		if *d.date, err = parseLongDate(line, d.f); err != nil {
+			return types.StatementHeader{}, err
+		}
+	}
+
+	bankCode, err := parseBankCode(line, statementHeaderBankCode)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.BankCode = bankCode
+
+	bankName, err := parseText(line, statementHeaderBankName)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.BankName = bankName
+
+	branchCode, err := parseBranchCode(line, statementHeaderBranchCode)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.BranchCode = branchCode
+
+	branchName, err := parseText(line, statementHeaderBranchName)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.BranchName = branchName
+
+	header.Dummy1 = statementHeaderDummy1.value(line)
+
+	accountType, err := parseAccountType(line, statementHeaderAccountType)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.AccountType = accountType
+
+	accountNumber, err := parseAccountNumber(line, statementHeaderAccountNumber)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.AccountNumber = accountNumber
+
+	accountName, err := parseText(line, statementHeaderAccountName)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.AccountName = accountName
+
+	header.BalanceBeforeOverdraft = statementHeaderOverdraft.value(line)
+	header.PassbookCategory = statementHeaderPassbookCategory.value(line)
+
+	balance, err := parseBalance(line, statementHeaderOverdraft, statementHeaderBalanceBefore)
+	if err != nil {
+		return types.StatementHeader{}, err
+	}
+	header.BalanceBefore = balance
+
+	if statementHeaderDummy2.in(line) {
+		header.Dummy2 = statementHeaderDummy2.value(line)
+	}
+
+	return header, nil
+}
+
+func parseStatementData(line record) (types.StatementData, error) {
+	if !statementDataDescription.in(line) {
+		return types.StatementData{}, recordError(line, errors.New("data line is too short"))
+	}
+
+	data := types.StatementData{}
+
+	recordType, err := parseRecordType(line, statementDataRecordType)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.RecordType = recordType
+
+	inquiryNumber, err := parseOptionalCode(line, statementDataInquiryNumber)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.InquiryNumber = inquiryNumber
+
+	accountingDate, err := parseLongDate(line, statementDataAccountingDate)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.AccountingDate = accountingDate
+
+	transactionDate, err := parseOptionalLongDate(line, statementDataTransactionDate)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.TransactionDate = transactionDate
+
+	entryType, err := parseEntryType(line, statementDataEntryType)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.EntryType = entryType
+
+	transactionType, err := parseTransactionType(line, statementDataTransactionType)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.TransactionType = transactionType
+
+	amount, err := parseAmount(line, statementDataAmount)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.Amount = amount
+
+	otherBankCheckAmount, err := parseAmount(line, statementDataOtherBankCheckAmount)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.OtherBankCheckAmount = otherBankCheckAmount
+
+	clearingDate, err := parseOptionalLongDate(line, statementDataClearingDate)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.ClearingDate = clearingDate
+
+	dishonorDate, err := parseOptionalLongDate(line, statementDataDishonorDate)
+	if err != nil {
+		return types.StatementData{}, err
+	}
+	data.DishonorDate = dishonorDate
+
+	data.BillType = statementDataBillType.value(line)
+
+	codes := []struct {
+		f    field
+		code *string
+	}{
+		{statementDataBillNumber, &data.BillNumber},
+		{statementDataBranchCode, &data.BranchCode},
+		{statementDataSenderCode, &data.SenderCode},
+	}
+	for _, c := range codes {
+		if *c.code, err = parseOptionalCode(line, c.f); err != nil {
+			return types.StatementData{}, err
+		}
+	}
+
+	texts := []struct {
+		f    field
+		text *string
+	}{
+		{statementDataSenderName, &data.SenderName},
+		{statementDataSenderBankName, &data.SenderBankName},
+		{statementDataSenderBranchName, &data.SenderBranchName},
+		{statementDataDescription, &data.Description},
+	}
+	for _, t := range texts {
+		if *t.text, err = parseText(line, t.f); err != nil {
+			return types.StatementData{}, err
+		}
+	}
+
+	// Fields below are optional
+
+	if statementDataEdiInformation.in(line) {
+		ediInformation, err := parseText(line, statementDataEdiInformation)
+		if err != nil {
+			return types.StatementData{}, err
+		}
+		data.EdiInformation = ediInformation
+	}
+
+	if statementDataDummy.in(line) {
+		data.Dummy = statementDataDummy.value(line)
+	}
+
+	return data, nil
+}
+
+func parseStatementTrailer(line record) (types.StatementTrailer, error) {
+	if !statementTrailerDataCount.in(line) {
+		return types.StatementTrailer{}, recordError(line, errors.New("trailer line too short"))
+	}
+
+	trailer := types.StatementTrailer{}
+
+	recordType, err := parseRecordType(line, statementTrailerRecordType)
+	if err != nil {
+		return types.StatementTrailer{}, err
+	}
+	trailer.RecordType = recordType
+
+	if trailer.DepositCount, err = parseCount(line, statementTrailerDepositCount); err != nil {
+		return types.StatementTrailer{}, err
+	}
+	if trailer.DepositAmount, err = parseAmount(line, statementTrailerDepositAmount); err != nil {
+		return types.StatementTrailer{}, err
+	}
+	if trailer.WithdrawalCount, err = parseCount(line, statementTrailerWithdrawalCount); err != nil {
+		return types.StatementTrailer{}, err
+	}
+	if trailer.WithdrawalAmount, err = parseAmount(line, statementTrailerWithdrawalAmount); err != nil {
+		return types.StatementTrailer{}, err
+	}
+	if trailer.BalanceAfter, err = parseBalance(line, statementTrailerOverdraft, statementTrailerBalanceAfter); err != nil {
+		return types.StatementTrailer{}, err
+	}
+	trailer.BalanceAfterOverdraft = statementTrailerOverdraft.value(line)
+	if trailer.DataCount, err = parseCount(line, statementTrailerDataCount); err != nil {
+		return types.StatementTrailer{}, err
+	}
+
+	if statementTrailerDummy.in(line) {
+		trailer.Dummy = statementTrailerDummy.value(line)
+	}
+
+	return trailer, nil
+}
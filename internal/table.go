@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column is a field of a transfer written as a CSV column, named after the field of the record layout
+type column struct {
+	field field
+	value func(t types.Transfer, labels bool) string
+}
+
+// columns are every header, data and trailer field but the record types and dummies, in record order
+var columns = []column{
+	{headerCategoryCode, func(t types.Transfer, _ bool) string {
+		if t.RawCategoryCode != "" {
+			return t.RawCategoryCode
+		}
+		return t.CategoryCode.Code()
+	}},
+	{headerEncodingType, func(t types.Transfer, _ bool) string { return t.EncodingType }},
+	{headerSenderCode, func(t types.Transfer, _ bool) string { return t.SenderCode }},
+	{headerSenderName, func(t types.Transfer, _ bool) string { return trimName(t.SenderName) }},
+	{headerTransferDate, func(t types.Transfer, _ bool) string { return t.TransferDate }},
+	{field{"1", "ResolvedTransferDate", "振込日", 0, 0}, func(t types.Transfer, _ bool) string {
+		if t.ResolvedTransferDate.IsZero() {
+			return ""
+		}
+		return t.ResolvedTransferDate.Format(time.DateOnly)
+	}},
+	{headerSenderBankCode, func(t types.Transfer, _ bool) string { return t.SenderBankCode }},
+	{headerSenderBankName, func(t types.Transfer, _ bool) string { return trimName(t.SenderBankName) }},
+	{headerSenderBranchCode, func(t types.Transfer, _ bool) string { return t.SenderBranchCode }},
+	{headerSenderBranchName, func(t types.Transfer, _ bool) string { return trimName(t.SenderBranchName) }},
+	{headerSenderAccountType, func(t types.Transfer, labels bool) string { return accountTypeColumn(t.SenderAccountType, labels) }},
+	{headerSenderAccountNumber, func(t types.Transfer, _ bool) string { return t.SenderAccountNumber }},
+	{dataRecipientBankCode, func(t types.Transfer, _ bool) string { return t.RecipientBankCode }},
+	{dataRecipientBankName, func(t types.Transfer, _ bool) string { return trimName(t.RecipientBankName) }},
+	{dataRecipientBranchCode, func(t types.Transfer, _ bool) string { return t.RecipientBranchCode }},
+	{dataRecipientBranchName, func(t types.Transfer, _ bool) string { return trimName(t.RecipientBranchName) }},
+	{dataExchangeOfficeCode, func(t types.Transfer, _ bool) string { return strings.TrimSpace(t.ExchangeOfficeCode) }},
+	{dataRecipientAccountType, func(t types.Transfer, labels bool) string { return accountTypeColumn(t.RecipientAccountType, labels) }},
+	{dataRecipientAccountNumber, func(t types.Transfer, _ bool) string { return t.RecipientAccountNumber }},
+	{dataRecipientName, func(t types.Transfer, _ bool) string { return trimName(t.RecipientName) }},
+	{dataAmount, func(t types.Transfer, _ bool) string { return strconv.FormatUint(t.Amount, 10) }},
+	{dataNewCode, func(t types.Transfer, _ bool) string { return strconv.Itoa(int(t.NewCode)) }},
+	{dataExtra, func(t types.Transfer, _ bool) string { return trimName(t.Extra) }},
+	{dataTransferCategory, func(t types.Transfer, _ bool) string { return strings.TrimSpace(t.TransferCategory) }},
+	{dataEdiPresent, func(t types.Transfer, _ bool) string {
+		if t.EdiPresent {
+			return "Y"
+		}
+		return ""
+	}},
+	{trailerTotalCount, func(t types.Transfer, _ bool) string { return strconv.Itoa(t.TotalCount) }},
+	{trailerTotalAmount, func(t types.Transfer, _ bool) string { return strconv.FormatUint(t.TotalAmount, 10) }},
+}
+
+// DefaultColumns are the columns of ToTable
+var DefaultColumns = []string{
+	"SenderName",
+	"RecipientBankCode",
+	"RecipientBranchCode",
+	"RecipientAccountType",
+	"RecipientAccountNumber",
+	"RecipientName",
+	"Amount",
+}
+
+// defaultHeaderJa is the header of ToTableJa, kept from before the columns could be selected
+var defaultHeaderJa = []string{
+	"振込名義人",
+	"金融機関コード",
+	"支店コード",
+	"科目",
+	"口座番号",
+	"口座名義人",
+	"金額",
+}
+
+// Columns returns the names of every column a TableWriter can write, in record order
+func Columns() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.field.name
+	}
+	return names
+}
+
+func lookupColumns(names []string) ([]column, error) {
+	result := make([]column, len(names))
+	for i, name := range names {
+		found := false
+		for _, c := range columns {
+			if c.field.name == name {
+				result[i], found = c, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+	}
+	return result, nil
+}
+
+// accountTypeColumn returns the account type as its digit, or as its 預金種目 such as 普通 with labels
+func accountTypeColumn(accountType types.AccountType, labels bool) string {
+	if labels {
+		return accountType.Description()
+	}
+	return strconv.Itoa(int(accountType))
+}
+
+func ToTable(t []types.Transfer) [][]string {
+	return toTable(t, DefaultColumns)
+}
+
+func ToTableJa(t []types.Transfer) [][]string {
+	return toTable(t, defaultHeaderJa)
+}
+
+func toTable(t []types.Transfer, header []string) [][]string {
+	selected, _ := lookupColumns(DefaultColumns)
+	result := [][]string{header}
+	for _, transfer := range t {
+		row := make([]string, len(selected))
+		for i, c := range selected {
+			row[i] = c.value(transfer, false)
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// TableWriter writes transfers as CSV or TSV, with a header row of the column names
+// in English (the field names of types) or in Japanese (the field names of the Zengin specification).
+type TableWriter struct {
+	writer   io.Writer
+	comma    rune
+	columns  []column
+	japanese bool
+	labels   bool
+	encoding types.Encoding
+	bom      bool
+	csv      *csv.Writer
+}
+
+func NewTableWriter(writer io.Writer, comma rune) *TableWriter {
+	selected, _ := lookupColumns(DefaultColumns)
+	return &TableWriter{writer: writer, comma: comma, columns: selected, encoding: types.EncodingUTF8}
+}
+
+// SetColumns selects the columns by their field names, such as "TransferDate", see Columns.
+// The columns of ToCSV are written by default.
+func (w *TableWriter) SetColumns(names ...string) error {
+	selected, err := lookupColumns(names)
+	if err != nil {
+		return err
+	}
+	w.columns = selected
+	return nil
+}
+
+// SetJapanese writes the header row with the field names of the Zengin specification, such as 振込指定日
+func (w *TableWriter) SetJapanese(japanese bool) {
+	w.japanese = japanese
+}
+
+// SetAccountTypeLabels writes account types as 普通, 当座, 貯蓄 or その他 instead of their digit
+func (w *TableWriter) SetAccountTypeLabels(labels bool) {
+	w.labels = labels
+}
+
+// SetEncoding writes in types.EncodingUTF8 (the default), types.EncodingShiftJIS or a registered encoding.
+// With bom, UTF-8 output starts with a byte order mark so that Excel reads it as UTF-8.
+func (w *TableWriter) SetEncoding(encoding types.Encoding, bom bool) {
+	w.encoding = encoding
+	w.bom = bom
+}
+
+// Write writes the transfers, after the header row on the first call
+func (w *TableWriter) Write(transfers []types.Transfer) error {
+	if w.csv == nil {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(w.columns))
+	for _, transfer := range transfers {
+		for i, c := range w.columns {
+			row[i] = c.value(transfer, w.labels)
+		}
+		if err := w.csv.Write(row); err != nil {
+			return err
+		}
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Flush writes the header row if nothing was written, so that a file without transfers still has its header
+func (w *TableWriter) Flush() error {
+	return w.Write(nil)
+}
+
+// start writes the byte order mark and the header row
+func (w *TableWriter) start() error {
+	writer := w.writer
+	if w.encoding == types.EncodingUTF8 {
+		if w.bom {
+			if _, err := io.WriteString(writer, "\uFEFF"); err != nil {
+				return err
+			}
+		}
+	} else {
+		encoding, err := lookupEncoding(w.encoding)
+		if err != nil {
+			return err
+		}
+		writer = encoding.NewEncoder().Writer(writer)
+	}
+
+	w.csv = csv.NewWriter(writer)
+	w.csv.Comma = w.comma
+	header := make([]string, len(w.columns))
+	for i, c := range w.columns {
+		header[i] = c.field.name
+		if w.japanese {
+			header[i] = c.field.nameJa
+		}
+	}
+	return w.csv.Write(header)
+}
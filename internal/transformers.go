@@ -13,30 +13,39 @@ func createTransfers(header types.Header, data []types.Data, trailer types.Trail
 	if trailer == (types.Trailer{}) {
 		return nil, fmt.Errorf("trailer is empty")
 	}
-	if len(data) != trailer.TotalCount {
-		return nil, fmt.Errorf("total count mismatch: %d != %d", len(data), trailer.TotalCount)
-	}
-	if trailer.TotalAmount != sumAmount(data) {
-		return nil, fmt.Errorf("total amount mismatch: %d != %d", trailer.TotalAmount, sumAmount(data))
-	}
 
 	var transfers []types.Transfer
-	var transfer types.Transfer
-	// Transfer Kyashが必要としているデータしかないですが、後で全部入れるようにする。
 	for _, block := range data {
-		transfer.SenderName = strings.TrimSpace(header.SenderName)
-		transfer.TransferDate = header.TransferDate
-		transfer.RecipientBankCode = block.RecipientBankCode
-		transfer.RecipientBranchCode = block.RecipientBranchCode
-		transfer.RecipientAccountType = block.RecipientAccountType
-		transfer.RecipientAccountNumber = block.RecipientAccountNumber
-		transfer.RecipientName = strings.TrimSpace(block.RecipientName)
-		transfer.Amount = block.Amount
+		transfer := createTransfer(header, block)
+		transfer.Trailer = trailer
 		transfers = append(transfers, transfer)
 	}
 	return transfers, nil
 }
 
+// createTransfer copies every header and data field into a transfer, with the padding of names trimmed
+func createTransfer(header types.Header, data types.Data) types.Transfer {
+	transfer := types.Transfer{Header: header, Data: data}
+	transfer.SenderName = strings.TrimSpace(header.SenderName)
+	transfer.SenderBankName = strings.TrimSpace(header.SenderBankName)
+	transfer.SenderBranchName = strings.TrimSpace(header.SenderBranchName)
+	transfer.RecipientBankName = strings.TrimSpace(data.RecipientBankName)
+	transfer.RecipientBranchName = strings.TrimSpace(data.RecipientBranchName)
+	transfer.RecipientName = strings.TrimSpace(data.RecipientName)
+	return transfer
+}
+
+// checkTrailer returns an error if the trailer totals do not match the data records
+func checkTrailer(data []types.Data, trailer types.Trailer) error {
+	if len(data) != trailer.TotalCount {
+		return fmt.Errorf("total count mismatch: %d != %d", len(data), trailer.TotalCount)
+	}
+	if trailer.TotalAmount != sumAmount(data) {
+		return fmt.Errorf("total amount mismatch: %d != %d", trailer.TotalAmount, sumAmount(data))
+	}
+	return nil
+}
+
 func sumAmount(data []types.Data) uint64 {
 	var sum uint64
 	for _, block := range data {
@@ -0,0 +1,378 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Encoder writes header groups as Zengin format records of types.RecordLength,
+// followed by an end record when closed.
+type Encoder struct {
+	writer     io.Writer
+	encoding   types.Encoding
+	lineEnding string
+	sanitize   bool
+	calendar   types.Calendar
+	end        types.End
+	// separator after the end record, the line ending of the other records if nil
+	endLineEnding *string
+	groups        int
+}
+
+func NewEncoder(writer io.Writer, encoding types.Encoding) *Encoder {
+	return &Encoder{
+		writer:     writer,
+		encoding:   encoding,
+		lineEnding: "\r\n",
+	}
+}
+
+// SetLineEnding sets the separator written after every record, "\r\n" by default.
+// An empty string writes the records back to back.
+func (e *Encoder) SetLineEnding(lineEnding string) {
+	e.lineEnding = lineEnding
+}
+
+// SetEnd sets the end record written by Close and the separator after it,
+// such as types.File.End and EndLineEnding. By default the end record is blank and followed by the line ending.
+func (e *Encoder) SetEnd(end types.End, lineEnding string) {
+	e.end = end
+	e.endLineEnding = &lineEnding
+}
+
+// SetSanitize converts names with kana.Sanitize before writing them, replacing the characters
+// that have no counterpart in the Zengin character set with spaces. By default such names are errors.
+func (e *Encoder) SetSanitize(sanitize bool) {
+	e.sanitize = sanitize
+}
+
+// SetCalendar rejects headers whose transfer date is not a business day, with an error wrapping types.ErrNotBusinessDay.
+// The date is Header.ResolvedTransferDate, which must be set, otherwise Encode returns an error wrapping types.ErrNoReferenceDate.
+func (e *Encoder) SetCalendar(calendar types.Calendar) {
+	e.calendar = calendar
+}
+
+// Encode writes a header record, its data records and a trailer record.
+// If trailer is nil it is computed from the data records, otherwise its totals must match them.
+// A blank TransferDate is written from Header.ResolvedTransferDate, such as a date returned by a calendar,
+// and a TransferDate other than the month and day of a set ResolvedTransferDate is an error.
+func (e *Encoder) Encode(header types.Header, data []types.Data, trailer *types.Trailer) error {
+	if err := e.checkTransferDate(&header); err != nil {
+		return err
+	}
+	if trailer == nil {
+		trailer = &types.Trailer{TotalCount: len(data), TotalAmount: sumAmount(data)}
+	} else if err := checkTrailer(data, *trailer); err != nil {
+		return err
+	}
+	if e.sanitize {
+		data = append([]types.Data(nil), data...)
+		sanitize(&header.SenderName, &header.SenderBankName, &header.SenderBranchName)
+		for i := range data {
+			sanitize(&data[i].RecipientBankName, &data[i].RecipientBranchName, &data[i].RecipientName, &data[i].Extra)
+		}
+	}
+
+	var records []string
+	record, err := formatHeader(header, e.encoding)
+	if err != nil {
+		return fmt.Errorf("error formatting header: %w", err)
+	}
+	records = append(records, record)
+	for i, block := range data {
+		record, err := formatData(block)
+		if err == nil && header.IsPayroll() {
+			err = checkPayrollData(newStringRecord(record), block)
+		}
+		if err != nil {
+			return fmt.Errorf("error formatting data record %d: %w", i+1, err)
+		}
+		records = append(records, record)
+	}
+	record, err = formatTrailer(*trailer)
+	if err != nil {
+		return fmt.Errorf("error formatting trailer record: %w", err)
+	}
+	records = append(records, record)
+
+	return e.write(records)
+}
+
+// sanitize replaces every name with its kana.Sanitize form
+func sanitize(names ...*string) {
+	for _, name := range names {
+		*name = kana.Sanitize(*name, ' ')
+	}
+}
+
+// write encodes a group of records and writes them at once,
+// so that an invalid record doesn't leave half a group behind
+func (e *Encoder) write(records []string) error {
+	var buf []byte
+	for _, record := range records {
+		encoded, err := e.encode(record)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, encoded...)
+	}
+	if _, err := e.writer.Write(buf); err != nil {
+		return err
+	}
+	e.groups++
+	return nil
+}
+
+// Close writes the end record. It doesn't close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.groups == 0 {
+		return errors.New("no header records written")
+	}
+	record, err := formatEnd(e.end)
+	if err != nil {
+		return fmt.Errorf("error formatting end record: %w", err)
+	}
+	lineEnding := e.lineEnding
+	if e.endLineEnding != nil {
+		lineEnding = *e.endLineEnding
+	}
+	encoded, err := e.encodeLine(record, lineEnding)
+	if err != nil {
+		return err
+	}
+	_, err = e.writer.Write(encoded)
+	return err
+}
+
+func (e *Encoder) encode(record string) ([]byte, error) {
+	return e.encodeLine(record, e.lineEnding)
+}
+
+// encodeLine encodes a record followed by lineEnding
+func (e *Encoder) encodeLine(record string, lineEnding string) ([]byte, error) {
+	if e.encoding == types.EncodingUTF8 {
+		return append([]byte(record), lineEnding...), nil
+	}
+
+	encoding, err := lookupEncoding(e.encoding)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := encoding.NewEncoder().Bytes([]byte(record))
+	if err != nil {
+		return nil, fmt.Errorf("record can't be encoded: %s", record)
+	}
+	if len(encoded) != types.RecordLength {
+		return nil, fmt.Errorf("record must be %d bytes, got %d (full-width characters?): %s",
+			types.RecordLength, len(encoded), record)
+	}
+	// The line ending is encoded too, as the line feed of EBCDIC code pages is not the ASCII one
+	ending, err := encoding.NewEncoder().Bytes([]byte(lineEnding))
+	if err != nil {
+		return nil, fmt.Errorf("line ending can't be encoded: %q", lineEnding)
+	}
+	return append(encoded, ending...), nil
+}
+
+func formatHeader(header types.Header, encoding types.Encoding) (string, error) {
+	var b recordBuilder
+	b.text(headerRecordType, "1")
+	b.category(headerCategoryCode, header.CategoryCode, header.RawCategoryCode)
+	b.code(headerEncodingType, defaultEncodingType(header.EncodingType, encoding))
+	b.code(headerSenderCode, header.SenderCode)
+	b.name(headerSenderName, header.SenderName)
+	b.date(headerTransferDate, header.TransferDate)
+	b.code(headerSenderBankCode, header.SenderBankCode)
+	b.name(headerSenderBankName, header.SenderBankName)
+	b.code(headerSenderBranchCode, header.SenderBranchCode)
+	b.name(headerSenderBranchName, header.SenderBranchName)
+	// Account fields are optional, and blank in headers parsed from short records
+	if header.SenderAccountType == types.AccountTypeUndefined {
+		b.text(headerSenderAccountType, "")
+	} else {
+		b.accountType(headerSenderAccountType, header.SenderAccountType)
+	}
+	b.optionalCode(headerSenderAccountNumber, header.SenderAccountNumber)
+	b.text(headerDummy, header.Dummy)
+	return b.String(), b.err
+}
+
+// defaultEncodingType returns encodingType, or the コード区分 of the encoding if it is empty
+func defaultEncodingType(encodingType string, encoding types.Encoding) string {
+	if encodingType != "" {
+		return encodingType
+	}
+	if encoding == types.EncodingShiftJIS {
+		return "0"
+	}
+	return "1"
+}
+
+func formatData(data types.Data) (string, error) {
+	var b recordBuilder
+	b.text(dataRecordType, "2")
+	b.code(dataRecipientBankCode, data.RecipientBankCode)
+	b.name(dataRecipientBankName, data.RecipientBankName)
+	b.code(dataRecipientBranchCode, data.RecipientBranchCode)
+	b.name(dataRecipientBranchName, data.RecipientBranchName)
+	b.optionalCode(dataExchangeOfficeCode, data.ExchangeOfficeCode)
+	b.accountType(dataRecipientAccountType, data.RecipientAccountType)
+	b.code(dataRecipientAccountNumber, data.RecipientAccountNumber)
+	b.name(dataRecipientName, data.RecipientName)
+	b.number(dataAmount, data.Amount)
+	b.newCode(dataNewCode, data.NewCode)
+	b.name(dataExtra, data.Extra)
+	b.optionalCode(dataTransferCategory, data.TransferCategory)
+	ediPresent := " "
+	if data.EdiPresent {
+		ediPresent = "Y"
+	}
+	b.text(dataEdiPresent, ediPresent)
+	b.text(dataDummy, data.Dummy)
+	return b.String(), b.err
+}
+
+func formatTrailer(trailer types.Trailer) (string, error) {
+	var b recordBuilder
+	b.text(trailerRecordType, "8")
+	b.number(trailerTotalCount, uint64(trailer.TotalCount))
+	b.number(trailerTotalAmount, trailer.TotalAmount)
+	b.text(trailerDummy, trailer.Dummy)
+	return b.String(), b.err
+}
+
+func formatEnd(end types.End) (string, error) {
+	var b recordBuilder
+	b.text(endRecordType, "9")
+	b.text(endDummy, end.Dummy)
+	return b.String(), b.err
+}
+
+// recordBuilder appends fields to a record in layout order and keeps the first error
+type recordBuilder struct {
+	strings.Builder
+	err error
+}
+
+// text left-aligns value in the field, padding with spaces
+func (b *recordBuilder) text(f field, value string) {
+	if b.err != nil {
+		return
+	}
+	width := f.end - f.start
+	length := utf8.RuneCountInString(value)
+	if length > width {
+		b.err = f.error(value, fmt.Errorf("must be at most %d characters", width))
+		return
+	}
+	b.WriteString(value)
+	b.WriteString(strings.Repeat(" ", width-length))
+}
+
+// name writes value like text, after checking that it only uses the Zengin character set
+func (b *recordBuilder) name(f field, value string) {
+	if b.err == nil {
+		if err := kana.Validate(value); err != nil {
+			b.err = f.error(value, err)
+			return
+		}
+	}
+	b.text(f, value)
+}
+
+// number right-aligns value in the field, padding with zeros
+func (b *recordBuilder) number(f field, value uint64) {
+	if b.err != nil {
+		return
+	}
+	width := f.end - f.start
+	digits := strconv.FormatUint(value, 10)
+	if len(digits) > width {
+		b.err = f.error(digits, fmt.Errorf("must be at most %d digits", width))
+		return
+	}
+	b.WriteString(strings.Repeat("0", width-len(digits)))
+	b.WriteString(digits)
+}
+
+// code writes value, which must fill the field with digits
+func (b *recordBuilder) code(f field, value string) {
+	if b.err != nil {
+		return
+	}
+	width := f.end - f.start
+	if len(value) != width || strings.Trim(value, "0123456789") != "" {
+		b.err = f.error(value, fmt.Errorf("must be %d digits", width))
+		return
+	}
+	b.WriteString(value)
+}
+
+// optionalCode writes value like code, or spaces if value is blank
+func (b *recordBuilder) optionalCode(f field, value string) {
+	if strings.TrimSpace(value) == "" {
+		b.text(f, "")
+		return
+	}
+	b.code(f, value)
+}
+
+func (b *recordBuilder) date(f field, date string) {
+	if err := checkDate(date); err != nil {
+		b.setErr(f.error(date, fmt.Errorf("invalid date: %w", err)))
+		return
+	}
+	b.code(f, date)
+}
+
+// category writes rawCategoryCode if it is given, as long as it matches categoryCode
+func (b *recordBuilder) category(f field, categoryCode types.CategoryCode, rawCategoryCode string) {
+	if rawCategoryCode != "" {
+		if types.ParseCategoryCode(rawCategoryCode) != categoryCode {
+			b.setErr(f.error(rawCategoryCode, errors.New("raw category code doesn't match the category code")))
+			return
+		}
+		b.text(f, rawCategoryCode)
+		return
+	}
+	switch categoryCode {
+	case types.CategoryCodeCombination:
+		b.text(f, "21")
+	case types.CategoryCodePayment:
+		b.text(f, "11")
+	case types.CategoryCodeBonus:
+		b.text(f, "12")
+	default:
+		b.setErr(f.error(strconv.Itoa(int(categoryCode)), errors.New("unknown category code")))
+	}
+}
+
+func (b *recordBuilder) accountType(f field, accountType types.AccountType) {
+	switch accountType {
+	case types.AccountTypeRegular, types.AccountTypeChecking, types.AccountTypeSavings, types.AccountTypeOther:
+		b.text(f, strconv.Itoa(int(accountType)))
+	default:
+		b.setErr(f.error(strconv.Itoa(int(accountType)), errors.New("invalid account type")))
+	}
+}
+
+func (b *recordBuilder) newCode(f field, newCode types.NewCode) {
+	switch newCode {
+	case types.CodeFirstTransfer, types.CodeUpdateTransfer, types.CodeOther:
+		b.text(f, strconv.Itoa(int(newCode)))
+	default:
+		b.setErr(f.error(strconv.Itoa(int(newCode)), errors.New("invalid new code")))
+	}
+}
+
+func (b *recordBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
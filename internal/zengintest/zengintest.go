@@ -0,0 +1,28 @@
+// Package zengintest builds the Zengin files used as fixtures by the tests of the module
+package zengintest
+
+import (
+	"golang.org/x/text/encoding/japanese"
+	"strings"
+	"testing"
+)
+
+// Pad pads s with spaces to n characters, as the text fields of the records are
+func Pad(s string, n int) string {
+	return s + strings.Repeat(" ", n-len([]rune(s)))
+}
+
+// File joins records into a file, ending every record with CRLF
+func File(records ...string) string {
+	return strings.Join(records, "\r\n") + "\r\n"
+}
+
+// ShiftJIS encodes a file in Shift-JIS, failing the test when it has characters out of the encoding
+func ShiftJIS(t testing.TB, file string) string {
+	t.Helper()
+	encoded, err := japanese.ShiftJIS.NewEncoder().String(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
@@ -0,0 +1,485 @@
+// Package iso20022 converts 総合振込 and 給与・賞与振込 header groups to ISO 20022 pain.001.001.09
+// CustomerCreditTransferInitiation documents and back.
+//
+// A header group is a payment information block (PmtInf) and its data records are the credit transfers
+// (CdtTrfTxInf) of the block. Bank and branch codes are the 7-digit member identification of the Zengin
+// clearing system (ClrSysMmbId with JPZGN), account types the proprietary account type (Tp/Prtry) of the digit
+// of the 預金種目, and 種別コード the proprietary local instrument (LclInstrm/Prtry). EDI情報 is the unstructured
+// remittance information (RmtInf/Ustrd) and 顧客コード1/2 are structured creditor references (RmtInf/Strd/CdtrRefInf/Ref),
+// with the proprietary type (Tp/CdOrPrtry/Prtry) "1" or "2". Blank customer codes have no reference.
+// The sender account is optional as in the header record: a blank account number has no DbtrAcct, and a blank
+// 預金種目 no Tp. Creditor accounts must have a Tp, and remittance information is either Ustrd or Strd.
+// コード区分, 手形交換所番号, 新規コード and 振込指定区分 have no counterpart and are lost.
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespace is the XML namespace of pain.001.001.09 documents
+const Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+
+// ClearingSystem is the code of the Zengin clearing system in ClrSysMmbId
+const ClearingSystem = "JPZGN"
+
+// Currency is the currency of every amount
+const Currency = "JPY"
+
+// Document is a pain.001.001.09 document, with the elements used by Zengin transfers
+type Document struct {
+	XMLName    xml.Name                         `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.09 Document"`
+	Initiation CustomerCreditTransferInitiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type CustomerCreditTransferInitiation struct {
+	GroupHeader        GroupHeader          `xml:"GrpHdr"`
+	PaymentInformation []PaymentInformation `xml:"PmtInf"`
+}
+
+type GroupHeader struct {
+	MessageID            string `xml:"MsgId"`
+	CreationDateTime     string `xml:"CreDtTm"`
+	NumberOfTransactions int    `xml:"NbOfTxs"`
+	ControlSum           uint64 `xml:"CtrlSum"`
+	InitiatingParty      Party  `xml:"InitgPty"`
+}
+
+type PaymentInformation struct {
+	PaymentInformationID   string              `xml:"PmtInfId"`
+	PaymentMethod          string              `xml:"PmtMtd"` // always TRF
+	NumberOfTransactions   int                 `xml:"NbOfTxs"`
+	ControlSum             uint64              `xml:"CtrlSum"`
+	PaymentTypeInformation *PaymentType        `xml:"PmtTpInf,omitempty"`
+	RequestedExecutionDate DateChoice          `xml:"ReqdExctnDt"`
+	Debtor                 Party               `xml:"Dbtr"`
+	DebtorAccount          *Account            `xml:"DbtrAcct,omitempty"` // nil for a blank sender account
+	DebtorAgent            Agent               `xml:"DbtrAgt"`
+	CreditTransfers        []CreditTransaction `xml:"CdtTrfTxInf"`
+}
+
+type PaymentType struct {
+	LocalInstrument *Proprietary `xml:"LclInstrm,omitempty"` // Prtry is the 種別コード
+	CategoryPurpose *Code        `xml:"CtgyPurp,omitempty"`  // SALA for 給与振込 and BONU for 賞与振込
+}
+
+type DateChoice struct {
+	Date string `xml:"Dt"` // YYYY-MM-DD
+}
+
+type Party struct {
+	Name           string          `xml:"Nm,omitempty"`
+	Identification *Identification `xml:"Id,omitempty"`
+}
+
+type Identification struct {
+	Organisation struct {
+		Other OtherIdentification `xml:"Othr"`
+	} `xml:"OrgId"`
+}
+
+type OtherIdentification struct {
+	ID         string       `xml:"Id"`
+	SchemeName *Proprietary `xml:"SchmeNm,omitempty"`
+}
+
+type Account struct {
+	Identification struct {
+		Other OtherIdentification `xml:"Othr"`
+	} `xml:"Id"`
+	Type *Proprietary `xml:"Tp,omitempty"` // Prtry is the digit of the 預金種目
+}
+
+type Agent struct {
+	FinancialInstitution FinancialInstitution `xml:"FinInstnId"`
+	Branch               *Branch              `xml:"BrnchId,omitempty"`
+}
+
+type FinancialInstitution struct {
+	ClearingSystemMember ClearingSystemMember `xml:"ClrSysMmbId"`
+	Name                 string               `xml:"Nm,omitempty"`
+}
+
+type ClearingSystemMember struct {
+	ClearingSystem Code   `xml:"ClrSysId"`
+	MemberID       string `xml:"MmbId"` // 4-digit bank code followed by the 3-digit branch code
+}
+
+type Branch struct {
+	ID   string `xml:"Id,omitempty"`
+	Name string `xml:"Nm,omitempty"`
+}
+
+type CreditTransaction struct {
+	PaymentID struct {
+		EndToEndID string `xml:"EndToEndId"`
+	} `xml:"PmtId"`
+	Amount struct {
+		Instructed Amount `xml:"InstdAmt"`
+	} `xml:"Amt"`
+	CreditorAgent         Agent       `xml:"CdtrAgt"`
+	Creditor              Party       `xml:"Cdtr"`
+	CreditorAccount       Account     `xml:"CdtrAcct"`
+	RemittanceInformation *Remittance `xml:"RmtInf,omitempty"`
+}
+
+type Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type Remittance struct {
+	Unstructured []string     `xml:"Ustrd,omitempty"`
+	Structured   []Structured `xml:"Strd,omitempty"`
+}
+
+type Structured struct {
+	CreditorReference struct {
+		Type      *CreditorReferenceType `xml:"Tp,omitempty"` // Prtry is "1" for 顧客コード1 and "2" for 顧客コード2
+		Reference string                 `xml:"Ref"`
+	} `xml:"CdtrRefInf"`
+}
+
+type CreditorReferenceType struct {
+	CodeOrProprietary Proprietary `xml:"CdOrPrtry"`
+}
+
+type Code struct {
+	Code string `xml:"Cd"`
+}
+
+type Proprietary struct {
+	Proprietary string `xml:"Prtry"`
+}
+
+// Convert returns the document of a header group with a single payment information block.
+// The requested execution date is Header.ResolvedTransferDate, or TransferDate resolved from created,
+// which is also the creation date and time of the message.
+func Convert(header types.Header, data []types.Data, messageID string, created time.Time) (*Document, error) {
+	date := header.ResolvedTransferDate
+	if date.IsZero() {
+		var err error
+		if date, err = header.ResolveTransferDate(created); err != nil {
+			return nil, fmt.Errorf("invalid transfer date %s: %w", header.TransferDate, err)
+		}
+	}
+
+	var total uint64
+	transactions := make([]CreditTransaction, len(data))
+	for i, d := range data {
+		transaction, err := creditTransaction(d, fmt.Sprintf("%s-%d", messageID, i+1))
+		if err != nil {
+			return nil, fmt.Errorf("data record %d: %w", i+1, err)
+		}
+		transactions[i] = transaction
+		total += d.Amount
+	}
+
+	categoryCode := header.RawCategoryCode
+	if categoryCode == "" {
+		categoryCode = header.CategoryCode.Code()
+	}
+	paymentType := &PaymentType{LocalInstrument: &Proprietary{categoryCode}}
+	switch header.CategoryCode {
+	case types.CategoryCodePayment:
+		paymentType.CategoryPurpose = &Code{"SALA"}
+	case types.CategoryCodeBonus:
+		paymentType.CategoryPurpose = &Code{"BONU"}
+	}
+
+	sender := Party{Name: strings.TrimSpace(header.SenderName), Identification: &Identification{}}
+	sender.Identification.Organisation.Other = OtherIdentification{ID: header.SenderCode, SchemeName: &Proprietary{"ZENGIN"}}
+	var debtorAccount *Account
+	if number := strings.TrimSpace(header.SenderAccountNumber); number != "" {
+		debtorAccount = &Account{}
+		debtorAccount.Identification.Other.ID = number
+		if header.SenderAccountType != types.AccountTypeUndefined {
+			debtorAccount.Type = &Proprietary{strconv.Itoa(int(header.SenderAccountType))}
+		}
+	}
+
+	return &Document{Initiation: CustomerCreditTransferInitiation{
+		GroupHeader: GroupHeader{
+			MessageID:            messageID,
+			CreationDateTime:     created.Format("2006-01-02T15:04:05"),
+			NumberOfTransactions: len(data),
+			ControlSum:           total,
+			InitiatingParty:      sender,
+		},
+		PaymentInformation: []PaymentInformation{{
+			PaymentInformationID:   messageID,
+			PaymentMethod:          "TRF",
+			NumberOfTransactions:   len(data),
+			ControlSum:             total,
+			PaymentTypeInformation: paymentType,
+			RequestedExecutionDate: DateChoice{date.Format(time.DateOnly)},
+			Debtor:                 sender,
+			DebtorAccount:          debtorAccount,
+			DebtorAgent:            agent(header.SenderBankCode, header.SenderBankName, header.SenderBranchCode, header.SenderBranchName),
+			CreditTransfers:        transactions,
+		}},
+	}}, nil
+}
+
+func creditTransaction(d types.Data, endToEndID string) (CreditTransaction, error) {
+	var t CreditTransaction
+	t.PaymentID.EndToEndID = endToEndID
+	t.Amount.Instructed = Amount{Currency: Currency, Value: strconv.FormatUint(d.Amount, 10)}
+	t.CreditorAgent = agent(d.RecipientBankCode, d.RecipientBankName, d.RecipientBranchCode, d.RecipientBranchName)
+	t.Creditor.Name = strings.TrimSpace(d.RecipientName)
+	t.CreditorAccount.Identification.Other.ID = d.RecipientAccountNumber
+	t.CreditorAccount.Type = &Proprietary{strconv.Itoa(int(d.RecipientAccountType))}
+
+	if d.EdiPresent {
+		edi, err := d.EdiInformation()
+		if err != nil {
+			return t, err
+		}
+		if edi != "" {
+			t.RemittanceInformation = &Remittance{Unstructured: []string{edi}}
+		}
+		return t, nil
+	}
+	code1, code2, err := d.CustomerCodes()
+	if err != nil {
+		return t, err
+	}
+	for i, code := range []string{code1, code2} {
+		if code == "" {
+			continue // an empty Ref is not a valid Max35Text
+		}
+		if t.RemittanceInformation == nil {
+			t.RemittanceInformation = &Remittance{}
+		}
+		var s Structured
+		s.CreditorReference.Type = &CreditorReferenceType{Proprietary{strconv.Itoa(i + 1)}}
+		s.CreditorReference.Reference = code
+		t.RemittanceInformation.Structured = append(t.RemittanceInformation.Structured, s)
+	}
+	return t, nil
+}
+
+func agent(bankCode, bankName, branchCode, branchName string) Agent {
+	a := Agent{FinancialInstitution: FinancialInstitution{
+		ClearingSystemMember: ClearingSystemMember{ClearingSystem: Code{ClearingSystem}, MemberID: bankCode + branchCode},
+		Name:                 strings.TrimSpace(bankName),
+	}}
+	a.Branch = &Branch{ID: branchCode, Name: strings.TrimSpace(branchName)}
+	return a
+}
+
+// Encode writes the document as XML with its declaration
+func (d *Document) Encode(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Decode reads a pain.001.001.09 document
+func Decode(r io.Reader) (*Document, error) {
+	var d Document
+	if err := xml.NewDecoder(r).Decode(&d); err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+	}
+	return &d, nil
+}
+
+// Groups returns a header group for every payment information block of the document,
+// with names normalized by kana.Normalize. Trailers are computed from the data records,
+// which must match the number of transactions and the control sum of their block.
+func (d *Document) Groups() ([]types.Group, error) {
+	if len(d.Initiation.PaymentInformation) == 0 {
+		return nil, errors.New("no payment information")
+	}
+	groups := make([]types.Group, len(d.Initiation.PaymentInformation))
+	for i, p := range d.Initiation.PaymentInformation {
+		group, err := paymentGroup(p, d.Initiation.GroupHeader)
+		if err != nil {
+			return nil, fmt.Errorf("payment information %s: %w", p.PaymentInformationID, err)
+		}
+		groups[i] = group
+	}
+	return groups, nil
+}
+
+func paymentGroup(p PaymentInformation, groupHeader GroupHeader) (types.Group, error) {
+	var group types.Group
+	h := &group.Header
+
+	h.CategoryCode = types.CategoryCodeCombination
+	if t := p.PaymentTypeInformation; t != nil {
+		if t.CategoryPurpose != nil {
+			switch t.CategoryPurpose.Code {
+			case "SALA":
+				h.CategoryCode = types.CategoryCodePayment
+			case "BONU":
+				h.CategoryCode = types.CategoryCodeBonus
+			}
+		}
+		if t.LocalInstrument != nil {
+			h.RawCategoryCode = t.LocalInstrument.Proprietary
+			h.CategoryCode = types.ParseCategoryCode(h.RawCategoryCode)
+			if !h.CategoryCode.IsTransfer() {
+				return group, fmt.Errorf("unknown local instrument: %s", h.RawCategoryCode)
+			}
+		}
+	}
+
+	date, err := time.Parse(time.DateOnly, p.RequestedExecutionDate.Date)
+	if err != nil {
+		return group, fmt.Errorf("invalid requested execution date: %w", err)
+	}
+	h.TransferDate = date.Format("0102")
+	h.ResolvedTransferDate = date
+
+	sender := p.Debtor
+	if sender.Identification == nil {
+		sender = groupHeader.InitiatingParty
+	}
+	if sender.Identification != nil {
+		h.SenderCode = sender.Identification.Organisation.Other.ID
+	}
+	h.SenderName = kana.Normalize(sender.Name)
+	if h.SenderBankCode, h.SenderBankName, h.SenderBranchCode, h.SenderBranchName, err = readAgent(p.DebtorAgent); err != nil {
+		return group, fmt.Errorf("debtor agent: %w", err)
+	}
+	if a := p.DebtorAccount; a != nil {
+		h.SenderAccountNumber = a.Identification.Other.ID
+		if h.SenderAccountType, err = readAccountType(a.Type); err != nil {
+			return group, fmt.Errorf("debtor account: %w", err)
+		}
+	}
+
+	for _, t := range p.CreditTransfers {
+		d, err := readTransaction(t)
+		if err != nil {
+			return group, fmt.Errorf("transaction %s: %w", t.PaymentID.EndToEndID, err)
+		}
+		group.Data = append(group.Data, d)
+		group.Trailer.TotalCount++
+		group.Trailer.TotalAmount += d.Amount
+	}
+	if p.NumberOfTransactions != 0 && p.NumberOfTransactions != group.Trailer.TotalCount {
+		return group, fmt.Errorf("number of transactions %d doesn't match %d transactions", p.NumberOfTransactions, group.Trailer.TotalCount)
+	}
+	if p.ControlSum != 0 && p.ControlSum != group.Trailer.TotalAmount {
+		return group, fmt.Errorf("control sum %d doesn't match the transactions: %d", p.ControlSum, group.Trailer.TotalAmount)
+	}
+	return group, nil
+}
+
+func readTransaction(t CreditTransaction) (types.Data, error) {
+	var d types.Data
+	if t.Amount.Instructed.Currency != Currency {
+		return d, fmt.Errorf("unsupported currency: %s", t.Amount.Instructed.Currency)
+	}
+	amount, err := readAmount(t.Amount.Instructed.Value)
+	if err != nil {
+		return d, err
+	}
+	d.Amount = amount
+
+	if d.RecipientBankCode, d.RecipientBankName, d.RecipientBranchCode, d.RecipientBranchName, err = readAgent(t.CreditorAgent); err != nil {
+		return d, fmt.Errorf("creditor agent: %w", err)
+	}
+	d.RecipientName = kana.Normalize(t.Creditor.Name)
+	d.RecipientAccountNumber = t.CreditorAccount.Identification.Other.ID
+	if t.CreditorAccount.Type == nil {
+		return d, errors.New("creditor account: missing account type")
+	}
+	if d.RecipientAccountType, err = readAccountType(t.CreditorAccount.Type); err != nil {
+		return d, fmt.Errorf("creditor account: %w", err)
+	}
+
+	if r := t.RemittanceInformation; r != nil && len(r.Unstructured) > 0 {
+		// EDI情報 and 顧客コード share the same field of the data record
+		if len(r.Structured) > 0 {
+			return d, errors.New("both unstructured and structured remittance information")
+		}
+		err = d.SetEdiInformation(strings.Join(r.Unstructured, ""))
+	} else {
+		var codes [2]string
+		if r != nil {
+			if codes, err = readCustomerCodes(r.Structured); err != nil {
+				return d, err
+			}
+		}
+		err = d.SetCustomerCodes(codes[0], codes[1])
+	}
+	return d, err
+}
+
+// readCustomerCodes returns 顧客コード1 and 顧客コード2 from the creditor references, placed by their type,
+// or in order for references without a type
+func readCustomerCodes(references []Structured) ([2]string, error) {
+	var codes [2]string
+	for i, s := range references {
+		position := i
+		if t := s.CreditorReference.Type; t != nil {
+			switch t.CodeOrProprietary.Proprietary {
+			case "1":
+				position = 0
+			case "2":
+				position = 1
+			default:
+				return codes, fmt.Errorf("unknown creditor reference type: %s", t.CodeOrProprietary.Proprietary)
+			}
+		}
+		if position >= len(codes) {
+			return codes, errors.New("more than 2 creditor references")
+		}
+		codes[position] = s.CreditorReference.Reference
+	}
+	return codes, nil
+}
+
+// readAmount parses a yen amount, which may have a fraction of zeros such as 1000.00
+func readAmount(value string) (uint64, error) {
+	yen, fraction, _ := strings.Cut(strings.TrimSpace(value), ".")
+	amount, err := strconv.ParseUint(yen, 10, 64)
+	if err != nil || strings.Trim(fraction, "0") != "" {
+		return 0, fmt.Errorf("invalid amount: %s", value)
+	}
+	return amount, nil
+}
+
+// readAgent returns the bank code and name and the branch code and name of an agent
+func readAgent(a Agent) (string, string, string, string, error) {
+	member := a.FinancialInstitution.ClearingSystemMember
+	if member.ClearingSystem.Code != ClearingSystem {
+		return "", "", "", "", fmt.Errorf("unsupported clearing system: %s", member.ClearingSystem.Code)
+	}
+	if len(member.MemberID) != 7 || strings.Trim(member.MemberID, "0123456789") != "" {
+		return "", "", "", "", fmt.Errorf("member identification must be 7 digits: %s", member.MemberID)
+	}
+	var branchName string
+	if a.Branch != nil {
+		branchName = kana.Normalize(a.Branch.Name)
+	}
+	return member.MemberID[:4], kana.Normalize(a.FinancialInstitution.Name), member.MemberID[4:], branchName, nil
+}
+
+// readAccountType returns the account type of a proprietary account type, AccountTypeUndefined if there is none
+func readAccountType(t *Proprietary) (types.AccountType, error) {
+	if t == nil {
+		return types.AccountTypeUndefined, nil
+	}
+	if accountType := types.ParseAccountType(t.Proprietary); accountType != types.AccountTypeUndefined {
+		return accountType, nil
+	}
+	return types.AccountTypeUndefined, fmt.Errorf("invalid account type: %s", t.Proprietary)
+}
@@ -0,0 +1,192 @@
+package iso20022_test
+
+import (
+	"bytes"
+	"github.com/Kyash/zengin-go"
+	"github.com/Kyash/zengin-go/internal/zengintest"
+	"github.com/Kyash/zengin-go/iso20022"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestISO20022(t *testing.T) {
+	input := zengintest.File(
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    010ﾎﾝﾃﾝ           20999999                 ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    000001000001234567890          0        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              41234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y       ",
+		"8000002000000010003                                                                                                     ",
+		"9                                                                                                                       ",
+	)
+	groups, err := zengin.ParseGroups(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2026, 2, 20, 10, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	document, err := iso20022.Convert(groups[0].Header, groups[0].Data, "MSG0001", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := document.Encode(&output); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">`,
+		"<NbOfTxs>2</NbOfTxs>", "<CtrlSum>10003</CtrlSum>", "<Dt>2026-02-24</Dt>",
+		"<Cd>JPZGN</Cd>", "<MmbId>2606020</MmbId>", "<Nm>ｹﾝｼﾝ ｼﾖｳｼﾞ</Nm>",
+		`<InstdAmt Ccy="JPY">10000</InstdAmt>`, "<Ustrd>ﾏｲﾂｷﾌﾞﾝ</Ustrd>",
+		"<CdOrPrtry>", "<Ref>1234567890</Ref>",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("expected %s in %s", expected, output.String())
+		}
+	}
+	if references := document.Initiation.PaymentInformation[0].CreditTransfers[0].RemittanceInformation.Structured; len(references) != 1 ||
+		references[0].CreditorReference.Type.CodeOrProprietary.Proprietary != "1" {
+		t.Errorf("expected only the reference of 顧客コード1, got %+v", references)
+	}
+	if strings.Contains(output.String(), "<Ref></Ref>") {
+		t.Errorf("expected no empty reference for the blank 顧客コード2 in %s", output.String())
+	}
+
+	decoded, err := iso20022.Decode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := decoded.Groups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, data := converted[0].Header, converted[0].Data
+	if header.TransferDate != "0224" || header.SenderCode != "0110999999" || header.SenderName != "ｹﾝｼﾝ ﾀﾛｳ" ||
+		header.SenderBranchName != "ﾎﾝﾃﾝ" || header.SenderAccountType != types.AccountTypeChecking || converted[0].Trailer.TotalAmount != 10003 {
+		t.Fatalf("unexpected header: %+v", converted[0])
+	}
+	if code1, _, _ := data[0].CustomerCodes(); code1 != "1234567890" || data[0].RecipientBranchCode != "020" || data[0].Amount != 10000 {
+		t.Errorf("unexpected first data record: %+v", data[0])
+	}
+	if edi, _ := data[1].EdiInformation(); edi != "ﾏｲﾂｷﾌﾞﾝ" || data[1].RecipientAccountType != types.AccountTypeSavings {
+		t.Errorf("unexpected second data record: %+v", data[1])
+	}
+	if err := zengin.Write(io.Discard, converted, types.EncodingShiftJIS); err != nil {
+		t.Fatal(err)
+	}
+
+	second := groups[0].Data[0]
+	if err := second.SetCustomerCodes("", "0987654321"); err != nil {
+		t.Fatal(err)
+	}
+	document, err = iso20022.Convert(groups[0].Header, []types.Data{second}, "MSG0002", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if references := document.Initiation.PaymentInformation[0].CreditTransfers[0].RemittanceInformation.Structured; len(references) != 1 {
+		t.Fatalf("expected only the reference of 顧客コード2, got %+v", references)
+	}
+	if converted, err := document.Groups(); err != nil {
+		t.Fatal(err)
+	} else if code1, code2, _ := converted[0].Data[0].CustomerCodes(); code1 != "" || code2 != "0987654321" {
+		t.Errorf("expected 顧客コード2 to keep its position, got %q and %q", code1, code2)
+	}
+
+	debtor := &document.Initiation.PaymentInformation[0].Debtor
+	debtor.Name, debtor.Identification = "ｹﾝｼﾝ ｼﾞﾛｳ", nil
+	if converted, err := document.Groups(); err != nil {
+		t.Fatal(err)
+	} else if header := converted[0].Header; header.SenderCode != "0110999999" || header.SenderName != "ｹﾝｼﾝ ﾀﾛｳ" {
+		t.Errorf("expected the sender code and name of the initiating party, got %q and %q", header.SenderCode, header.SenderName)
+	}
+
+	// Documents are checked where Zengin records have no room for them
+	for _, tt := range []struct {
+		name  string
+		edit  func(p *iso20022.PaymentInformation)
+		error string
+	}{
+		{"missing creditor account type", func(p *iso20022.PaymentInformation) {
+			p.CreditTransfers[0].CreditorAccount.Type = nil
+		}, "missing account type"},
+		{"both remittance informations", func(p *iso20022.PaymentInformation) {
+			p.CreditTransfers[1].RemittanceInformation.Structured = p.CreditTransfers[0].RemittanceInformation.Structured
+		}, "both unstructured and structured"},
+		{"member identification with a letter", func(p *iso20022.PaymentInformation) {
+			p.CreditTransfers[0].CreditorAgent.FinancialInstitution.ClearingSystemMember.MemberID = "26O6020"
+		}, "7 digits"},
+	} {
+		document, err := iso20022.Convert(groups[0].Header, groups[0].Data, "MSG0003", created)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.edit(&document.Initiation.PaymentInformation[0])
+		if _, err := document.Groups(); err == nil || !strings.Contains(err.Error(), tt.error) {
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.error, err)
+		}
+	}
+
+	decoded.Initiation.PaymentInformation[0].ControlSum = 10004
+	if _, err := decoded.Groups(); err == nil {
+		t.Fatal("expected error for a control sum that doesn't match, got nil")
+	}
+}
+
+func TestBlankDebtorAccount(t *testing.T) {
+	input := zengintest.File(
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010                                        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        ",
+		"8000001000000000001                                                                                                     ",
+		"9                                                                                                                       ",
+	)
+	groups, err := zengin.ParseGroups(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document, err := iso20022.Convert(groups[0].Header, groups[0].Data, "MSG0001", time.Date(2026, 2, 20, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := document.Encode(&output); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output.String(), "<DbtrAcct>") {
+		t.Fatalf("expected no debtor account in %s", output.String())
+	}
+	decoded, err := iso20022.Decode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := decoded.Groups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := converted[0].Header; header.SenderAccountType != types.AccountTypeUndefined || header.SenderAccountNumber != "" {
+		t.Fatalf("expected a blank sender account, got %+v", header)
+	}
+	var written bytes.Buffer
+	if err := zengin.Write(&written, converted, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	// 新規コード is lost, but the header record is written back as parsed
+	if header, _, _ := strings.Cut(written.String(), "\r\n"); !strings.HasPrefix(input, header+"\r\n") {
+		t.Fatalf("expected the header record of %q, got %q", input, header)
+	}
+
+	// A debtor account without a type keeps a blank 預金種目
+	groups[0].Header.SenderAccountNumber = "0999999"
+	if document, err = iso20022.Convert(groups[0].Header, groups[0].Data, "MSG0002", time.Date(2026, 2, 20, 10, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if account := document.Initiation.PaymentInformation[0].DebtorAccount; account == nil || account.Type != nil {
+		t.Fatalf("expected a debtor account without a type, got %+v", account)
+	}
+	if converted, err := document.Groups(); err != nil {
+		t.Fatal(err)
+	} else if header := converted[0].Header; header.SenderAccountType != types.AccountTypeUndefined || header.SenderAccountNumber != "0999999" {
+		t.Fatalf("expected a sender account without a type, got %+v", header)
+	}
+}
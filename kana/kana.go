@@ -0,0 +1,81 @@
+// Package kana normalizes and validates names in the character set of the Zengin format:
+// digits, upper case letters, half-width katakana without small kana, space and a few symbols.
+package kana
+
+import (
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+	"strings"
+	"unicode"
+)
+
+// IsAllowed reports whether r is in the Zengin character set
+func IsAllowed(r rune) bool {
+	switch {
+	case '0' <= r && r <= '9', 'A' <= r && r <= 'Z':
+		return true
+	case 'ｱ' <= r && r <= 'ﾟ', r == 'ｦ': // ｱ to ﾝ, ﾞ and ﾟ
+		return true
+	}
+	switch r {
+	case ' ', '(', ')', '-', '.', '/', ',', '\\', '¥', '｢', '｣':
+		return true
+	}
+	return false
+}
+
+// Validate returns an error for the first character of s outside the Zengin character set
+func Validate(s string) error {
+	for i, r := range []rune(s) {
+		if !IsAllowed(r) {
+			return fmt.Errorf("character %q at position %d is not allowed", r, i+1)
+		}
+	}
+	return nil
+}
+
+var (
+	// marks replaces combining voiced sound marks, as split by NFD, with their half-width forms
+	marks = strings.NewReplacer("\u3099", "ﾞ", "\u309a", "ﾟ")
+	// large replaces the half-width forms that are not in the character set once full-width characters are narrowed
+	large = strings.NewReplacer(
+		"ｧ", "ｱ", "ｨ", "ｲ", "ｩ", "ｳ", "ｪ", "ｴ", "ｫ", "ｵ", "ｬ", "ﾔ", "ｭ", "ﾕ", "ｮ", "ﾖ", "ｯ", "ﾂ",
+		"ヮ", "ﾜ", "ヵ", "ｶ", "ヶ", "ｹ", "ヰ", "ｲ", "ヱ", "ｴ",
+		"ｰ", "-", "‐", "-", "―", "-", "—", "-", "−", "-", "～", "-",
+		"･", ".",
+	)
+)
+
+// Normalize converts s towards the Zengin character set: hiragana and full-width katakana to half-width katakana,
+// voiced katakana to a katakana followed by ﾞ or ﾟ, small kana to large kana, long vowel marks and dashes to '-',
+// full-width letters, digits and symbols to their half-width forms and lower case letters to upper case.
+// Characters without a counterpart in the character set are kept, see Validate and Sanitize.
+func Normalize(s string) string {
+	s = norm.NFD.String(s)
+	s = strings.Map(func(r rune) rune {
+		if 'ぁ' <= r && r <= 'ゖ' { // hiragana to katakana
+			return r + 'ァ' - 'ぁ'
+		}
+		return r
+	}, s)
+	s = marks.Replace(s)
+	s = width.Narrow.String(s)
+	s = strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' {
+			return unicode.ToUpper(r)
+		}
+		return r
+	}, s)
+	return large.Replace(s)
+}
+
+// Sanitize normalizes s and replaces the characters still outside the Zengin character set with replacement
+func Sanitize(s string, replacement rune) string {
+	return strings.Map(func(r rune) rune {
+		if IsAllowed(r) {
+			return r
+		}
+		return replacement
+	}, Normalize(s))
+}
@@ -0,0 +1,30 @@
+package kana_test
+
+import (
+	"github.com/Kyash/zengin-go/kana"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ケンシン　ショウジ", "ｹﾝｼﾝ ｼﾖｳｼﾞ"},
+		{"けんしん ぱーく", "ｹﾝｼﾝ ﾊﾟ-ｸ"},
+		{"ｹﾝｼﾝ ｼｮｳｼﾞ", "ｹﾝｼﾝ ｼﾖｳｼﾞ"},
+		{"Kenshin（カブ）", "KENSHIN(ｶﾌﾞ)"},
+		{"ﾃｽﾄ・ﾀﾛｳ", "ﾃｽﾄ.ﾀﾛｳ"},
+	}
+	for _, tt := range tests {
+		if actual := kana.Normalize(tt.input); actual != tt.expected {
+			t.Errorf("Normalize(%q): expected %q, got %q", tt.input, tt.expected, actual)
+		}
+		if err := kana.Validate(kana.Normalize(tt.input)); err != nil {
+			t.Errorf("Validate(Normalize(%q)): %v", tt.input, err)
+		}
+	}
+	if actual := kana.Sanitize("ｹﾝｼﾝ★ﾀﾛｳ", ' '); actual != "ｹﾝｼﾝ ﾀﾛｳ" {
+		t.Errorf("expected ★ to be replaced, got %q", actual)
+	}
+}
@@ -0,0 +1,133 @@
+// Package namematch compares account holder names for 名義照合, such as RecipientName against the name on file,
+// tolerating the variations of Zengin names.
+package namematch
+
+import (
+	"github.com/Kyash/zengin-go/corpname"
+	"github.com/Kyash/zengin-go/kana"
+	"github.com/Kyash/zengin-go/types"
+	"strings"
+)
+
+// Result is the outcome of comparing two names
+type Result struct {
+	Score  float64 // 1 for the same names, lower the more they differ
+	Reason string  // first variation needed for the names to match, or why they don't
+}
+
+// Match reports whether the names match with a score of at least threshold
+func (r Result) Match(threshold float64) bool {
+	return r.Score >= threshold
+}
+
+// step is a variation that is ignored from then on, with the score of the names that match once it is ignored
+type step struct {
+	score  float64
+	reason string
+	apply  func(corpname.Name) corpname.Name
+}
+
+var steps = []step{
+	{1, "same name", func(n corpname.Name) corpname.Name { return n }},
+	{0.95, "same name ignoring spaces", func(n corpname.Name) corpname.Name {
+		n.Body, n.Rest = strings.ReplaceAll(n.Body, " ", ""), strings.ReplaceAll(n.Rest, " ", "")
+		return n
+	}},
+	{0.95, "same name with the corporate abbreviation placed differently", func(n corpname.Name) corpname.Name {
+		if n.Position != corpname.PositionNone {
+			n.Position = corpname.PositionBefore
+		}
+		return n
+	}},
+	{0.9, "same name ignoring long vowel marks", func(n corpname.Name) corpname.Name {
+		n.Body, n.Rest = strings.ReplaceAll(n.Body, "-", ""), strings.ReplaceAll(n.Rest, "-", "")
+		return n
+	}},
+	{0.8, "same name ignoring voiced sound marks", func(n corpname.Name) corpname.Name {
+		marks := strings.NewReplacer("ﾞ", "", "ﾟ", "")
+		n.Body, n.Rest = marks.Replace(n.Body), marks.Replace(n.Rest)
+		return n
+	}},
+}
+
+// Compare compares two names after normalizing them with kana.Normalize, which makes full-width and small kana
+// match the Zengin forms, and splitting them with corpname.Parse, which makes abbreviations match entity types.
+// Names that still differ are scored by the edit distance of their bodies, halved if their entity types differ.
+func Compare(expected, actual string) Result {
+	x, y := corpname.Parse(kana.Normalize(expected)), corpname.Parse(kana.Normalize(actual))
+	for _, s := range steps {
+		x, y = s.apply(x), s.apply(y)
+		if x == y {
+			return Result{Score: s.score, Reason: s.reason}
+		}
+	}
+
+	a, b := []rune(x.Body+x.Rest), []rune(y.Body+y.Rest)
+	score := 1.0
+	// Names made of an entity type only, such as ｶ) and ﾕ), have the same empty bodies
+	if length := max(len(a), len(b)); length > 0 {
+		score -= float64(distance(a, b)) / float64(length)
+	}
+	if x.Entity != y.Entity {
+		return Result{Score: score * 0.5, Reason: "different corporate entity types"}
+	}
+	return Result{Score: score * 0.8, Reason: "different names"}
+}
+
+// distance returns the Levenshtein distance between a and b
+func distance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// Lookup returns the name on file of a recipient, or false if there is none
+type Lookup func(data types.Data) (string, bool)
+
+// Suspicious is a data record whose recipient name doesn't match the name on file
+type Suspicious struct {
+	Index    int // index of the data record
+	Data     types.Data
+	Expected string // name on file, empty if there is none
+	Result   Result
+}
+
+// CheckData compares the recipient name of every data record with the name on file,
+// and returns the records without a name on file or scoring below threshold
+func CheckData(data []types.Data, lookup Lookup, threshold float64) []Suspicious {
+	var suspicious []Suspicious
+	for i, d := range data {
+		expected, ok := lookup(d)
+		if !ok {
+			suspicious = append(suspicious, Suspicious{Index: i, Data: d, Result: Result{Reason: "no name on file"}})
+			continue
+		}
+		if result := Compare(expected, d.RecipientName); !result.Match(threshold) {
+			suspicious = append(suspicious, Suspicious{Index: i, Data: d, Expected: expected, Result: result})
+		}
+	}
+	return suspicious
+}
+
+// CheckTransfers is CheckData for the transfers returned by zengin.Parse
+func CheckTransfers(transfers []types.Transfer, lookup Lookup, threshold float64) []Suspicious {
+	data := make([]types.Data, len(transfers))
+	for i, transfer := range transfers {
+		data[i] = transfer.Data
+	}
+	return CheckData(data, lookup, threshold)
+}
@@ -0,0 +1,41 @@
+package namematch_test
+
+import (
+	"github.com/Kyash/zengin-go/namematch"
+	"github.com/Kyash/zengin-go/types"
+	"testing"
+)
+
+func TestNameMatch(t *testing.T) {
+	tests := []struct {
+		expected, actual string
+		score            float64
+	}{
+		{"ｶ)ｷﾔﾂｼﾕ", "株式会社キャッシュ", 1},
+		{"ｹﾝｼﾝ ﾀﾛｳ", "ｹﾝｼﾝﾀﾛｳ", 0.95},
+		{"ｶ)ｷﾔﾂｼﾕ", "ｷﾔﾂｼﾕ(ｶ", 0.95},
+		{"ｺ-ﾋ-ｼﾖｳｼﾞ", "ｺﾋｼﾖｳｼﾞ", 0.9},
+		{"ｹﾞﾝｷ ﾊﾅｺ", "ｹﾝｷ ﾊﾅｺ", 0.8},
+	}
+	for _, tt := range tests {
+		if result := namematch.Compare(tt.expected, tt.actual); result.Score != tt.score {
+			t.Errorf("Compare(%q, %q): expected %v, got %+v", tt.expected, tt.actual, tt.score, result)
+		}
+	}
+	if result := namematch.Compare("ｶ)ｷﾔﾂｼﾕ", "ﾕ)ｷﾔﾂｼﾕ"); result.Match(0.8) || result.Reason != "different corporate entity types" {
+		t.Errorf("expected different entity types not to match, got %+v", result)
+	}
+	if result := namematch.Compare("ｶ)", "ﾕ)"); result.Score != 0.5 || result.Reason != "different corporate entity types" {
+		t.Errorf("expected entity types without a name to score 0.5, got %+v", result)
+	}
+
+	data := []types.Data{{RecipientName: "ｷﾔﾂｼﾕ(ｶ"}, {RecipientName: "ﾔﾏﾀﾞ ｼﾞﾛｳ"}, {RecipientName: "ｽｽﾞｷ ﾊﾅｺ"}}
+	names := map[string]string{"ｷﾔﾂｼﾕ(ｶ": "株式会社キャッシュ", "ﾔﾏﾀﾞ ｼﾞﾛｳ": "ヤマダ タロウ"}
+	suspicious := namematch.CheckData(data, func(d types.Data) (string, bool) {
+		name, ok := names[d.RecipientName]
+		return name, ok
+	}, 0.8)
+	if len(suspicious) != 2 || suspicious[0].Index != 1 || suspicious[1].Index != 2 || suspicious[1].Result.Reason != "no name on file" {
+		t.Fatalf("expected the 2nd and 3rd recipients to be suspicious, got %+v", suspicious)
+	}
+}
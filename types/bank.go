@@ -0,0 +1,33 @@
+package types
+
+// Bank is an entry of the 金融機関コード list
+type Bank struct {
+	Code        string // 4 digits
+	Name        string // name in kanji, such as みずほ
+	Kana        string // name in Zengin half-width kana, such as ﾐｽﾞﾎ
+	BranchCount int    // number of branches listed in the directory, 0 if they are not listed
+}
+
+// Branch is an entry of the 店舗コード list of a bank
+type Branch struct {
+	BankCode string // 4 digits
+	Code     string // 3 digits
+	Name     string // name in kanji
+	Kana     string // name in Zengin half-width kana
+}
+
+// BankDirectory looks up banks and branches by code, see the bankdir package for the implementations
+type BankDirectory interface {
+	// Bank returns the bank with the code, or false if it is unknown
+	Bank(code string) (Bank, bool)
+	// Branch returns the branch of a bank, or false if it is unknown
+	Branch(bankCode, branchCode string) (Branch, bool)
+}
+
+// PartialDirectory is a BankDirectory that doesn't list every bank, such as a snapshot of the major banks.
+// Unknown bank codes are only warnings with a partial directory.
+type PartialDirectory interface {
+	BankDirectory
+	// Partial reports whether banks may be missing from the directory
+	Partial() bool
+}
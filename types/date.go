@@ -0,0 +1,52 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotBusinessDay is returned for a transfer date on which banks are closed, see Calendar
+var ErrNotBusinessDay = errors.New("not a business day")
+
+// ErrNoReferenceDate is returned for a Calendar given without the date from which the transfer dates are resolved,
+// so that checking them doesn't depend on the current time
+var ErrNoReferenceDate = errors.New("no reference date to resolve the transfer date")
+
+// ErrDateNotCovered is returned for a transfer date outside the years of a LimitedCalendar
+var ErrDateNotCovered = errors.New("date not covered by the calendar")
+
+// Calendar tells the days on which banks are open, see the calendar package for the implementation
+type Calendar interface {
+	// IsBusinessDay reports whether banks are open on the day of date
+	IsBusinessDay(date time.Time) bool
+}
+
+// LimitedCalendar is a Calendar that only knows the holidays of some years.
+// Transfer dates outside them are errors wrapping ErrDateNotCovered.
+type LimitedCalendar interface {
+	Calendar
+	// Covers reports whether the holidays of the year of date are known
+	Covers(date time.Time) bool
+}
+
+// ResolveDate returns the first MMDD date on or after the day of reference, in the location of reference.
+// Only the year of reference and the following year are tried, so that 0229 is an error in most years.
+func ResolveDate(mmdd string, reference time.Time) (time.Time, error) {
+	date, err := time.Parse("0102", mmdd)
+	if err != nil {
+		return time.Time{}, err
+	}
+	year, month, day := reference.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, reference.Location())
+	for _, y := range []int{year, year + 1} {
+		resolved := time.Date(y, date.Month(), date.Day(), 0, 0, 0, 0, reference.Location())
+		if resolved.Day() != date.Day() { // February 29 of a common year
+			continue
+		}
+		if !resolved.Before(today) {
+			return resolved, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no date %s within a year from %s", mmdd, today.Format(time.DateOnly))
+}
@@ -0,0 +1,130 @@
+package types
+
+import "strconv"
+
+// 口座振替 (direct debit, 種別コード 91) files.
+// The same layout is used for the 口座振替依頼 file sent to the bank and the 振替結果 file it returns,
+// which has a 振替結果コード in every data record and the result totals in the trailer.
+
+type DebitHeader struct {
+	RecordType    string       // 1 digit
+	CategoryCode  CategoryCode // 2 digits, always CategoryCodeDebit
+	EncodingType  string       // 1 digit
+	ConsignorCode string       // 10 digits, 委託者コード
+	ConsignorName string       // 40 characters, 委託者名
+	DebitDate     string       // 4 digits (MMDD), 引落日
+	BankCode      string       // 4 digits, 取引銀行番号
+	BankName      string       // 15 characters
+	BranchCode    string       // 3 digits
+	BranchName    string       // 15 characters
+	AccountType   AccountType  // 1 digit, account of the consignor
+	AccountNumber string       // 7 digits
+	Dummy         string       // 17 characters (unused)
+}
+
+type DebitData struct {
+	RecordType     string      // 1 digit
+	BankCode       string      // 4 digits, 引落銀行番号
+	BankName       string      // 15 characters
+	BranchCode     string      // 3 digits
+	BranchName     string      // 15 characters
+	Dummy1         string      // 4 characters (unused)
+	AccountType    AccountType // 1 digit
+	AccountNumber  string      // 7 digits
+	AccountName    string      // 30 characters, 預金者名
+	Amount         uint64      // 10 digits, 引落金額
+	NewCode        NewCode     // 1 digit
+	CustomerNumber string      // 20 characters, 顧客番号
+	ResultCode     ResultCode  // 1 digit, 振替結果コード, ResultCodeTransferred ("0") in request files
+	Dummy2         string      // 8 characters (unused)
+}
+
+type DebitTrailer struct {
+	RecordType        string // 1 digit
+	TotalCount        int    // 6 digits
+	TotalAmount       uint64 // 12 digits
+	TransferredCount  int    // 6 digits, 振替済件数, zero in request files
+	TransferredAmount uint64 // 12 digits, 振替済金額
+	FailedCount       int    // 6 digits, 振替不能件数
+	FailedAmount      uint64 // 12 digits, 振替不能金額
+	Dummy             string // 65 characters (unused)
+}
+
+// DebitGroup is one header record of a 口座振替 file with its data records and trailer record
+type DebitGroup struct {
+	Header  DebitHeader
+	Data    []DebitData
+	Trailer DebitTrailer
+}
+
+// DebitResult is the outcome of parsing a 口座振替 file
+type DebitResult struct {
+	Groups      []DebitGroup  // header groups without errors
+	Diagnostics []*ParseError // every error found in lenient mode, in file order
+	Encoding    Encoding      // encoding detected or forced by the options
+}
+
+type ResultCode int // 振替結果コード, the digit of the file
+
+const (
+	ResultCodeTransferred        ResultCode = 0  // 振替済, also written in request files
+	ResultCodeInsufficientFunds  ResultCode = 1  // 資金不足
+	ResultCodeNoAccount          ResultCode = 2  // 取引なし
+	ResultCodeStoppedByDepositor ResultCode = 3  // 預金者の都合による振替停止
+	ResultCodeNoAuthorization    ResultCode = 4  // 預金口座振替依頼書なし
+	ResultCodeStoppedByConsignor ResultCode = 8  // 委託者の都合による振替停止
+	ResultCodeOther              ResultCode = 9  // その他
+	ResultCodeBlank              ResultCode = -2 // blank, as some request files leave it
+	ResultCodeUndefined          ResultCode = -1
+)
+
+// ParseResultCode returns the result code of a digit of the file, ResultCodeBlank for a space,
+// or ResultCodeUndefined if it is not a result code
+func ParseResultCode(code string) ResultCode {
+	if code == " " {
+		return ResultCodeBlank
+	}
+	if len(code) == 1 && code[0] >= '0' && code[0] <= '9' {
+		if c := ResultCode(code[0] - '0'); c.Description() != "不明" {
+			return c
+		}
+	}
+	return ResultCodeUndefined
+}
+
+// Code returns the digit of the result code in the file, a space for ResultCodeBlank, or an empty string
+// if it is not a result code
+func (c ResultCode) Code() string {
+	switch {
+	case c == ResultCodeBlank:
+		return " "
+	case c.Description() == "不明":
+		return ""
+	default:
+		return strconv.Itoa(int(c))
+	}
+}
+
+// Description returns the meaning of the result code as written in the Zengin specification
+func (c ResultCode) Description() string {
+	switch c {
+	case ResultCodeBlank:
+		return "未設定"
+	case ResultCodeTransferred:
+		return "振替済"
+	case ResultCodeInsufficientFunds:
+		return "資金不足"
+	case ResultCodeNoAccount:
+		return "取引なし"
+	case ResultCodeStoppedByDepositor:
+		return "預金者の都合による振替停止"
+	case ResultCodeNoAuthorization:
+		return "預金口座振替依頼書なし"
+	case ResultCodeStoppedByConsignor:
+		return "委託者の都合による振替停止"
+	case ResultCodeOther:
+		return "その他"
+	default:
+		return "不明"
+	}
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrReadFailure is returned when the file couldn't be read, wrapping the error of the reader
+	ErrReadFailure = errors.New("couldn't read from file")
+	// ErrNoTransfers is returned by Parse for a valid file without any data record
+	ErrNoTransfers = errors.New("no transfers found in file")
+)
+
+// ParseError reports a record or a field of a record that couldn't be parsed, with its position in the file.
+// Use errors.As to get it from the errors returned by the parser.
+type ParseError struct {
+	Record     int    // 1-based index of the record in the file, the line number for files with line breaks
+	RecordType string // "1" header, "2" data, "8" trailer or "9" end
+	Field      string // name of the field in the types structs, empty if the error is about the whole record
+	FieldJa    string // name of the field in the Zengin specification
+	Start      int    // 0-based offset of the field in the record, in bytes, or in characters for UTF-8 files
+	End        int    // offset just after the field
+	Value      string // raw value of the field
+	Err        error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	if e.Record > 0 {
+		fmt.Fprintf(&b, "line %d, ", e.Record)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, "field %s %s (cols %d-%d), value '%s': ", e.FieldJa, e.Field, e.Start+1, e.End, e.Value)
+	} else if e.RecordType != "" {
+		fmt.Fprintf(&b, "record type %s: ", e.RecordType)
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// GroupError reports a trailer record whose totals don't match the data records of its header group
+type GroupError struct {
+	Group   int // 1-based position of the header group in the file
+	Header  Header
+	Trailer Trailer
+	Count   int    // number of data records in the group
+	Amount  uint64 // sum of the data record amounts
+	Err     error
+}
+
+func (e *GroupError) Error() string {
+	return fmt.Sprintf("header group %d: %v", e.Group, e.Err)
+}
+
+func (e *GroupError) Unwrap() error {
+	return e.Err
+}
+
+// RowError reports a row of a CSV file that couldn't be imported as a data record
+type RowError struct {
+	Row    int    // 1-based line of the row in the CSV file, the header row being line 1
+	Column string // header of the column, empty if the error is about the whole row
+	Field  string // name of the field in the types structs, empty if the error is about the whole row
+	Value  string // value of the column as in the file
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("row %d, column %s (%s), value '%s': %v", e.Row, e.Column, e.Field, e.Value, e.Err)
+	}
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
+	"strings"
+)
+
+const (
+	extraLength        = 20 // characters of Data.Extra
+	customerCodeLength = 10 // digits of 顧客コード1 and 顧客コード2
+)
+
+// CustomerCodes returns 顧客コード1 and 顧客コード2 from Extra. It returns an error if Extra holds EDI information
+// or a code that is neither 10 digits nor blank.
+func (d Data) CustomerCodes() (string, string, error) {
+	if d.EdiPresent {
+		return "", "", errors.New("extra holds EDI information, not customer codes")
+	}
+	extra := []rune(d.Extra + strings.Repeat(" ", max(0, extraLength-len([]rune(d.Extra)))))
+	code1, code2 := string(extra[0:customerCodeLength]), string(extra[customerCodeLength:extraLength])
+	if err := checkCustomerCode(code1); err != nil {
+		return "", "", fmt.Errorf("顧客コード1: %w", err)
+	}
+	if err := checkCustomerCode(code2); err != nil {
+		return "", "", fmt.Errorf("顧客コード2: %w", err)
+	}
+	return strings.TrimSpace(code1), strings.TrimSpace(code2), nil
+}
+
+// EdiInformation returns the EDI情報 from Extra with its padding trimmed, normalized by kana.Normalize.
+// It returns an error if Extra holds customer codes.
+func (d Data) EdiInformation() (string, error) {
+	if !d.EdiPresent {
+		return "", errors.New("extra holds customer codes, not EDI information")
+	}
+	return strings.TrimRight(kana.Normalize(d.Extra), " "), nil
+}
+
+// SetCustomerCodes sets Extra to 顧客コード1 and 顧客コード2, each of them 10 digits or empty,
+// and clears EdiPresent
+func (d *Data) SetCustomerCodes(code1, code2 string) error {
+	for i, code := range []string{code1, code2} {
+		if code == "" {
+			continue
+		}
+		if len(code) != customerCodeLength || strings.Trim(code, "0123456789") != "" {
+			return fmt.Errorf("顧客コード%d must be %d digits: %s", i+1, customerCodeLength, code)
+		}
+	}
+	d.Extra = fmt.Sprintf("%-10s%-10s", code1, code2)
+	d.EdiPresent = false
+	return nil
+}
+
+// SetEdiInformation sets Extra to the EDI情報 text normalized by kana.Normalize, and sets EdiPresent.
+// It returns an error if the text is too long or has characters outside the Zengin character set.
+func (d *Data) SetEdiInformation(text string) error {
+	text = kana.Normalize(text)
+	if n := len([]rune(text)); n > extraLength {
+		return fmt.Errorf("EDI information must be at most %d characters, got %d", extraLength, n)
+	}
+	if err := kana.Validate(text); err != nil {
+		return fmt.Errorf("EDI information: %w", err)
+	}
+	d.Extra = text
+	d.EdiPresent = true
+	return nil
+}
+
+func checkCustomerCode(code string) error {
+	if strings.TrimSpace(code) == "" {
+		return nil
+	}
+	if strings.Trim(code, "0123456789") != "" {
+		return fmt.Errorf("must be %d digits: %s", customerCodeLength, code)
+	}
+	return nil
+}
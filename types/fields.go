@@ -2,22 +2,52 @@ package types
 
 import (
 	"strings"
+	"time"
 )
 
 type Header struct {
 	RecordType          string       // 1 digit
 	CategoryCode        CategoryCode // 2 digits
+	RawCategoryCode     string       // 種別コード as in the file, to tell 11 from 71 and 12 from 72
 	EncodingType        string       // 1 digit
-	SenderCode          string       // 10 digits
-	SenderName          string       // 40 characters
+	SenderCode          string       // 10 digits, 会社コード in 給与・賞与振込
+	SenderName          string       // 40 characters, 会社名 in 給与・賞与振込
 	TransferDate        string       // 4 digits (MMDD)
 	SenderBankCode      string       // 4 digits
 	SenderBankName      string       // 15 characters
 	SenderBranchCode    string       // 3 digits
 	SenderBranchName    string       // 15 characters
-	SenderAccountType   AccountType  // 1 digit
-	SenderAccountNumber string       // 7 digits
+	SenderAccountType   AccountType  // 1 digit, AccountTypeUndefined if blank
+	SenderAccountNumber string       // 7 digits or blank
 	Dummy               string       // 17 characters (unused)
+	// TransferDate with its year, resolved when parsed with a reference date or a calendar, see ResolveDate
+	ResolvedTransferDate time.Time
+}
+
+// IsPayroll reports whether the header is of a 給与振込 or 賞与振込 file
+func (h Header) IsPayroll() bool {
+	return h.CategoryCode == CategoryCodePayment || h.CategoryCode == CategoryCodeBonus
+}
+
+// ResolveTransferDate returns TransferDate as the first such date on or after reference, see ResolveDate
+func (h Header) ResolveTransferDate(reference time.Time) (time.Time, error) {
+	return ResolveDate(h.TransferDate, reference)
+}
+
+// CompanyCode returns the 会社コード of a 給与・賞与振込 header, or an empty string for other files
+func (h Header) CompanyCode() string {
+	if !h.IsPayroll() {
+		return ""
+	}
+	return h.SenderCode
+}
+
+// CompanyName returns the 会社名 of a 給与・賞与振込 header, or an empty string for other files
+func (h Header) CompanyName() string {
+	if !h.IsPayroll() {
+		return ""
+	}
+	return h.SenderName
 }
 
 type Data struct {
@@ -32,7 +62,7 @@ type Data struct {
 	RecipientName          string      // 30 characters
 	Amount                 uint64      // 10 digits
 	NewCode                NewCode     // 1 digit (unused)
-	// Next 20 characters can be used for CustomerCode1&2, or EDIInformation
+	// Next 20 characters can be used for CustomerCode1&2, or EDIInformation, see CustomerCodes and EdiInformation
 	Extra            string // 20 characters
 	TransferCategory string // 1 digit (unused)
 	EdiPresent       bool   // 1 character, if "Y", EDIInformation is used
@@ -46,6 +76,11 @@ type Trailer struct {
 	Dummy       string // 101 characters (unused)
 }
 
+type End struct {
+	RecordType string // 1 digit
+	Dummy      string // 119 characters (unused)
+}
+
 // helper functions
 
 func IsHeader(line []rune) bool {
@@ -0,0 +1,162 @@
+package types
+
+// ParseCategoryCode returns the category of a 種別コード, or CategoryCodeUndefined if it is unknown
+func ParseCategoryCode(code string) CategoryCode {
+	switch code {
+	case "21":
+		return CategoryCodeCombination
+	case "11", "71":
+		return CategoryCodePayment
+	case "12", "72":
+		return CategoryCodeBonus
+	case "91":
+		return CategoryCodeDebit
+	case "01":
+		return CategoryCodeNotification
+	case "03":
+		return CategoryCodeStatement
+	default:
+		return CategoryCodeUndefined
+	}
+}
+
+// IsTransfer reports whether the category is 総合振込 or 給与・賞与振込, the files of types.Header
+func (c CategoryCode) IsTransfer() bool {
+	return c == CategoryCodeCombination || c == CategoryCodePayment || c == CategoryCodeBonus
+}
+
+// Name returns the category in English, such as "combination", or "undefined"
+func (c CategoryCode) Name() string {
+	switch c {
+	case CategoryCodeCombination:
+		return "combination"
+	case CategoryCodePayment:
+		return "payment"
+	case CategoryCodeBonus:
+		return "bonus"
+	case CategoryCodeDebit:
+		return "debit"
+	case CategoryCodeNotification:
+		return "notification"
+	case CategoryCodeStatement:
+		return "statement"
+	default:
+		return "undefined"
+	}
+}
+
+// Description returns the category as written in the Zengin specification, such as 総合振込, or 不明
+func (c CategoryCode) Description() string {
+	switch c {
+	case CategoryCodeCombination:
+		return "総合振込"
+	case CategoryCodePayment:
+		return "給与振込"
+	case CategoryCodeBonus:
+		return "賞与振込"
+	case CategoryCodeDebit:
+		return "口座振替"
+	case CategoryCodeNotification:
+		return "振込入金通知"
+	case CategoryCodeStatement:
+		return "入出金取引明細"
+	default:
+		return "不明"
+	}
+}
+
+// Code returns the 種別コード of the category, the one of 民間企業 for 給与・賞与振込, or an empty string
+func (c CategoryCode) Code() string {
+	switch c {
+	case CategoryCodeCombination:
+		return "21"
+	case CategoryCodePayment:
+		return "11"
+	case CategoryCodeBonus:
+		return "12"
+	case CategoryCodeDebit:
+		return "91"
+	case CategoryCodeNotification:
+		return "01"
+	case CategoryCodeStatement:
+		return "03"
+	default:
+		return ""
+	}
+}
+
+// ParseAccountType returns the account type of a 預金種目 digit, or AccountTypeUndefined if it is unknown
+func ParseAccountType(code string) AccountType {
+	switch code {
+	case "1":
+		return AccountTypeRegular
+	case "2":
+		return AccountTypeChecking
+	case "4":
+		return AccountTypeSavings
+	case "9":
+		return AccountTypeOther
+	default:
+		return AccountTypeUndefined
+	}
+}
+
+// Name returns the account type in English, such as "regular", or "undefined"
+func (t AccountType) Name() string {
+	switch t {
+	case AccountTypeRegular:
+		return "regular"
+	case AccountTypeChecking:
+		return "checking"
+	case AccountTypeSavings:
+		return "savings"
+	case AccountTypeOther:
+		return "other"
+	default:
+		return "undefined"
+	}
+}
+
+// Description returns the 預金種目 of the account type, such as 普通, or 不明
+func (t AccountType) Description() string {
+	switch t {
+	case AccountTypeRegular:
+		return "普通"
+	case AccountTypeChecking:
+		return "当座"
+	case AccountTypeSavings:
+		return "貯蓄"
+	case AccountTypeOther:
+		return "その他"
+	default:
+		return "不明"
+	}
+}
+
+// Name returns the 新規コード in English, such as "first_transfer", or "undefined"
+func (c NewCode) Name() string {
+	switch c {
+	case CodeFirstTransfer:
+		return "first_transfer"
+	case CodeUpdateTransfer:
+		return "update_transfer"
+	case CodeOther:
+		return "other"
+	default:
+		return "undefined"
+	}
+}
+
+// Description returns the 新規コード as written in the Zengin specification, such as 第1回振込分, or 不明
+func (c NewCode) Description() string {
+	switch c {
+	case CodeFirstTransfer:
+		return "第1回振込分"
+	case CodeUpdateTransfer:
+		return "変更分"
+	case CodeOther:
+		return "その他"
+	default:
+		return "不明"
+	}
+}
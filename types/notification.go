@@ -0,0 +1,61 @@
+package types
+
+// 振込入金通知 (incoming credit notification, 種別コード 01) files, sent by banks for the credits
+// into an account. Records are LongRecordLength long.
+
+type NotificationHeader struct {
+	RecordType         string       // 1 digit
+	CategoryCode       CategoryCode // 2 digits, always CategoryCodeNotification
+	EncodingType       string       // 1 digit
+	CreatedDate        string       // 6 digits (YYMMDD), 作成日
+	AccountingDateFrom string       // 6 digits (YYMMDD), 勘定日(自)
+	AccountingDateTo   string       // 6 digits (YYMMDD), 勘定日(至)
+	BankCode           string       // 4 digits
+	BankName           string       // 15 characters
+	BranchCode         string       // 3 digits
+	BranchName         string       // 15 characters
+	Dummy1             string       // 3 characters (unused)
+	AccountType        AccountType  // 1 digit
+	AccountNumber      string       // 10 digits
+	AccountName        string       // 40 characters
+	Dummy2             string       // 87 characters (unused)
+}
+
+type NotificationData struct {
+	RecordType           string // 1 digit
+	InquiryNumber        string // 6 digits, 照会番号
+	AccountingDate       string // 6 digits (YYMMDD), 勘定日
+	ValueDate            string // 6 digits (YYMMDD), 起算日
+	Amount               uint64 // 10 digits
+	OtherBankCheckAmount uint64 // 10 digits, うち他店券金額
+	SenderCode           string // 10 digits, 振込依頼人コード, blank if not given
+	SenderName           string // 48 characters, 振込依頼人名
+	SenderBankName       string // 15 characters, 仕向銀行名
+	SenderBranchName     string // 15 characters, 仕向店名
+	Cancelled            bool   // 1 character, 取消区分, if "1" the credit was cancelled
+	EdiInformation       string // 20 characters
+	Dummy                string // 52 characters (unused)
+}
+
+type NotificationTrailer struct {
+	RecordType      string // 1 digit
+	TotalCount      int    // 6 digits, credits that are not cancelled
+	TotalAmount     uint64 // 12 digits
+	CancelledCount  int    // 6 digits, 取消合計件数
+	CancelledAmount uint64 // 12 digits
+	Dummy           string // 163 characters (unused)
+}
+
+// NotificationGroup is one header record of a 振込入金通知 file with its data records and trailer record
+type NotificationGroup struct {
+	Header  NotificationHeader
+	Data    []NotificationData
+	Trailer NotificationTrailer
+}
+
+// NotificationResult is the outcome of parsing a 振込入金通知 file
+type NotificationResult struct {
+	Groups      []NotificationGroup // header groups without errors
+	Diagnostics []*ParseError       // every error found in lenient mode, in file order
+	Encoding    Encoding            // encoding detected or forced by the options
+}
@@ -0,0 +1,120 @@
+package types
+
+// 入出金取引明細 (statement of deposits and withdrawals, 種別コード 03) files, sent by banks with the transactions
+// of one or more accounts, a header group per account. Records are LongRecordLength long.
+// The 預金残高報告 (balance report) format is out of the scope of this package: ParseStatement only reads
+// 種別コード 03 and returns an error on the CategoryCode of any other file.
+
+type StatementHeader struct {
+	RecordType             string       // 1 digit
+	CategoryCode           CategoryCode // 2 digits, always CategoryCodeStatement
+	EncodingType           string       // 1 digit
+	CreatedDate            string       // 6 digits (YYMMDD), 作成日
+	AccountingDateFrom     string       // 6 digits (YYMMDD), 勘定日(自)
+	AccountingDateTo       string       // 6 digits (YYMMDD), 勘定日(至)
+	BankCode               string       // 4 digits
+	BankName               string       // 15 characters
+	BranchCode             string       // 3 digits
+	BranchName             string       // 15 characters
+	Dummy1                 string       // 3 characters (unused)
+	AccountType            AccountType  // 1 digit
+	AccountNumber          string       // 10 digits
+	AccountName            string       // 40 characters
+	BalanceBeforeOverdraft string       // 1 digit, 貸越区分 of BalanceBefore: "1" if positive, "2" if overdrawn
+	PassbookCategory       string       // 1 digit, 通帳・証書区分
+	BalanceBefore          int64        // 14 digits, 取引前残高, negative when overdrawn
+	Dummy2                 string       // 71 characters (unused)
+}
+
+type StatementData struct {
+	RecordType           string          // 1 digit
+	InquiryNumber        string          // 8 digits, 照会番号
+	AccountingDate       string          // 6 digits (YYMMDD), 勘定日
+	TransactionDate      string          // 6 digits (YYMMDD), 預入・払出日
+	EntryType            EntryType       // 1 digit, 入払区分
+	TransactionType      TransactionType // 2 digits, 取引区分
+	Amount               uint64          // 12 digits, 取引金額
+	OtherBankCheckAmount uint64          // 12 digits, うち他店券金額
+	ClearingDate         string          // 6 digits (YYMMDD), 交換呈示日, blank if not given
+	DishonorDate         string          // 6 digits (YYMMDD), 不渡返還日, blank if not given
+	BillType             string          // 1 digit, 手形・小切手区分
+	BillNumber           string          // 7 digits, 手形・小切手番号
+	BranchCode           string          // 3 digits, 僚店番号
+	SenderCode           string          // 10 digits, 振込依頼人コード
+	SenderName           string          // 48 characters, 振込依頼人名または契約者番号
+	SenderBankName       string          // 15 characters, 仕向銀行名
+	SenderBranchName     string          // 15 characters, 仕向店名
+	Description          string          // 20 characters, 摘要内容
+	EdiInformation       string          // 20 characters
+	Dummy                string          // 1 character (unused)
+}
+
+type StatementTrailer struct {
+	RecordType            string // 1 digit
+	DepositCount          int    // 6 digits, 入金件数
+	DepositAmount         uint64 // 13 digits, 入金額合計
+	WithdrawalCount       int    // 6 digits, 出金件数
+	WithdrawalAmount      uint64 // 13 digits, 出金額合計
+	BalanceAfterOverdraft string // 1 digit, 貸越区分 of BalanceAfter: "1" if positive, "2" if overdrawn
+	BalanceAfter          int64  // 14 digits, 取引後残高, negative when overdrawn
+	DataCount             int    // 7 digits, データ・レコード件数
+	Dummy                 string // 139 characters (unused)
+}
+
+// StatementGroup is the header record of one account in a 入出金取引明細 file,
+// with its transactions and trailer record
+type StatementGroup struct {
+	Header  StatementHeader
+	Data    []StatementData
+	Trailer StatementTrailer
+}
+
+// StatementResult is the outcome of parsing a 入出金取引明細 file
+type StatementResult struct {
+	Groups      []StatementGroup // header groups without errors
+	Diagnostics []*ParseError    // every error found in lenient mode, in file order
+	Encoding    Encoding         // encoding detected or forced by the options
+}
+
+type EntryType int // 入払区分
+
+const (
+	EntryTypeUndefined  EntryType = 0
+	EntryTypeDeposit    EntryType = 1 // 入金
+	EntryTypeWithdrawal EntryType = 2 // 出金
+)
+
+type TransactionType int // 取引区分
+
+const (
+	TransactionTypeUndefined  TransactionType = 0
+	TransactionTypeCash       TransactionType = 10 // 現金
+	TransactionTypeTransfer   TransactionType = 11 // 振込
+	TransactionTypeOtherBank  TransactionType = 12 // 他店券入金
+	TransactionTypeClearing   TransactionType = 13 // 交換(取立入金および交換払)
+	TransactionTypeBookEntry  TransactionType = 14 // 振替
+	TransactionTypeOther      TransactionType = 18 // その他
+	TransactionTypeCorrection TransactionType = 19 // 訂正
+)
+
+// Description returns the meaning of the transaction type as written in the Zengin specification
+func (t TransactionType) Description() string {
+	switch t {
+	case TransactionTypeCash:
+		return "現金"
+	case TransactionTypeTransfer:
+		return "振込"
+	case TransactionTypeOtherBank:
+		return "他店券入金"
+	case TransactionTypeClearing:
+		return "交換"
+	case TransactionTypeBookEntry:
+		return "振替"
+	case TransactionTypeOther:
+		return "その他"
+	case TransactionTypeCorrection:
+		return "訂正"
+	default:
+		return "不明"
+	}
+}
@@ -7,6 +7,9 @@ const (
 	MinDataLength    = 91 // until "新規コード"
 	MinTrailerLength = 19 // until "dummy"
 	MinEndLength     = 1  // until "dummy"
+
+	RecordLength     = 120 // bytes in Shift-JIS, characters in UTF-8
+	LongRecordLength = 200 // records of 振込入金通知 and other files sent by banks
 )
 
 const (
@@ -15,19 +18,55 @@ const (
 	EncodingUTF8
 )
 
+// LineEnding is the policy for the line breaks between records
+type LineEnding int
+
+const (
+	LineEndingAny  LineEnding = iota // CRLF or LF
+	LineEndingCRLF                   // every line break must be CRLF
+	LineEndingLF                     // CRLF is not allowed
+)
+
+// RecordFormat is how the records of a file are separated
+type RecordFormat int
+
+const (
+	RecordFormatAuto  RecordFormat = iota // fixed-length if there is no line break within the first record
+	RecordFormatLines                     // one record per line
+	RecordFormatFixed                     // records of RecordLength (LongRecordLength in files sent by banks) back to back, without line breaks
+)
+
 type Transfer struct {
 	Header
 	Data
 	Trailer
 }
 
+// Group is one header record with its data records and trailer record, as they appear in a file
+type Group struct {
+	Header  Header
+	Data    []Data
+	Trailer Trailer
+}
+
+// File is every header group of a file with its end record and line endings, as they appear in the file
+type File struct {
+	Groups        []Group
+	End           End
+	LineEnding    string // after every record but the end record: "\r\n", "\n", or empty for fixed-length records
+	EndLineEnding string // after the end record, often empty
+}
+
 type CategoryCode int
 
 const (
-	CategoryCodeUndefined CategoryCode = iota
-	CategoryCodeCombination
-	CategoryCodePayment
-	CategoryCodeBonus
+	CategoryCodeUndefined    CategoryCode = iota
+	CategoryCodeCombination               // 総合振込, 21
+	CategoryCodePayment                   // 給与振込, 11 or 71
+	CategoryCodeBonus                     // 賞与振込, 12 or 72
+	CategoryCodeDebit                     // 口座振替, see DebitHeader
+	CategoryCodeNotification              // 振込入金通知, see NotificationHeader
+	CategoryCodeStatement                 // 入出金取引明細, see StatementHeader
 )
 
 type AccountType int
@@ -37,6 +76,7 @@ const (
 	AccountTypeRegular   AccountType = 1
 	AccountTypeChecking  AccountType = 2
 	AccountTypeSavings   AccountType = 4
+	AccountTypeOther     AccountType = 9 // not allowed in 給与・賞与振込
 )
 
 type NewCode int // 新規コード
@@ -47,3 +87,11 @@ const (
 	CodeOther                  = 0
 	CodeUndefined              = -1
 )
+
+// ParseResult is the outcome of parsing a file with options
+type ParseResult struct {
+	Transfers   []Transfer    // transfers of the header groups without errors
+	Diagnostics []*ParseError // every error found, in file order
+	Warnings    []*ParseError // bank and branch names that don't match the bank directory, kept in the transfers
+	Encoding    Encoding      // encoding detected or forced by the options
+}
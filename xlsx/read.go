@@ -0,0 +1,328 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/kana"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xmlText is a string of sharedStrings.xml or an inline string, plain or made of rich text runs
+type xmlText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlText) String() string {
+	s := t.Text
+	for _, run := range t.Runs {
+		s += run.Text
+	}
+	return s
+}
+
+type xmlSharedStrings struct {
+	Items []xmlText `xml:"si"`
+}
+
+type xmlWorksheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string  `xml:"r,attr"`
+			Type   string  `xml:"t,attr"`
+			Value  string  `xml:"v"`
+			Inline xmlText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// Read reads the header groups of a workbook written by Write, possibly edited in Excel, in the order of its sheets.
+// Codes that Excel turned into numbers get their leading zeros back.
+// The totals row must match the data records, and becomes the Trailer of the group.
+func Read(r io.ReaderAt, size int64) ([]types.Group, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrReadFailure, err)
+	}
+
+	var book xmlWorkbook
+	if err := decodePart(archive, "xl/workbook.xml", &book); err != nil {
+		return nil, err
+	}
+	var rels xmlRelationships
+	if err := decodePart(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var shared xmlSharedStrings
+	if err := decodePart(archive, "xl/sharedStrings.xml", &shared); err != nil && !errors.Is(err, errMissingPart) {
+		return nil, err
+	}
+
+	var groups []types.Group
+	for _, sheet := range book.Sheets {
+		target := ""
+		for _, rel := range rels.Relationships {
+			if rel.ID == sheet.ID {
+				target = rel.Target
+			}
+		}
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		var worksheet xmlWorksheet
+		if err := decodePart(archive, target, &worksheet); err != nil {
+			return nil, err
+		}
+		group, err := readGroup(cells(worksheet, shared))
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 {
+		return nil, types.ErrNoTransfers
+	}
+	return groups, nil
+}
+
+var errMissingPart = errors.New("missing part")
+
+func decodePart(archive *zip.Reader, name string, v any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errMissingPart, name)
+	}
+	defer file.Close()
+	if err := xml.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %w", types.ErrReadFailure, name, err)
+	}
+	return nil
+}
+
+// cells returns the values of a sheet by 1-based row and 0-based column
+func cells(worksheet xmlWorksheet, shared xmlSharedStrings) map[int]map[int]string {
+	result := map[int]map[int]string{}
+	for i, row := range worksheet.Rows {
+		index := row.Index
+		if index == 0 {
+			index = i + 1
+		}
+		result[index] = map[int]string{}
+		for j, c := range row.Cells {
+			column := j
+			if c.Ref != "" {
+				column = columnIndex(c.Ref)
+			}
+			value := c.Value
+			switch c.Type {
+			case "s":
+				if n, err := strconv.Atoi(c.Value); err == nil && n < len(shared.Items) {
+					value = shared.Items[n].String()
+				}
+			case "inlineStr":
+				value = c.Inline.String()
+			}
+			result[index][column] = value
+		}
+	}
+	return result
+}
+
+// columnIndex returns the 0-based column of a cell reference, such as 0 for A1 and 26 for AA1
+func columnIndex(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A') + 1
+	}
+	return column - 1
+}
+
+// readGroup returns the header group of the cells of a sheet
+func readGroup(cells map[int]map[int]string) (types.Group, error) {
+	var group types.Group
+	row := cells[2]
+	h := &group.Header
+	h.RawCategoryCode = row[0]
+	h.CategoryCode = types.ParseCategoryCode(row[0])
+	if !h.CategoryCode.IsTransfer() {
+		return group, cellError(2, 0, row[0], errors.New("unknown category code"))
+	}
+	h.EncodingType = row[1]
+	for _, c := range []struct {
+		column int
+		value  *string
+	}{{3, &h.SenderName}, {6, &h.SenderBankName}, {8, &h.SenderBranchName}} {
+		if err := kana.Validate(row[c.column]); err != nil {
+			return group, cellError(2, c.column, row[c.column], err)
+		}
+		*c.value = row[c.column]
+	}
+	var err error
+	for _, c := range []struct {
+		column int
+		length int
+		value  *string
+	}{{2, 10, &h.SenderCode}, {4, 4, &h.TransferDate}, {5, 4, &h.SenderBankCode}, {7, 3, &h.SenderBranchCode}} {
+		if *c.value, err = readCode(row[c.column], c.length); err != nil {
+			return group, cellError(2, c.column, row[c.column], err)
+		}
+	}
+	// The sender account is optional, as in the header record
+	if strings.TrimSpace(row[9]) != "" {
+		if h.SenderAccountType, err = readAccountType(row[9]); err != nil {
+			return group, cellError(2, 9, row[9], err)
+		}
+	}
+	if h.SenderAccountNumber, err = readOptionalCode(row[10], 7); err != nil {
+		return group, cellError(2, 10, row[10], err)
+	}
+
+	var amount uint64
+	for r := dataRow; ; r++ {
+		row, ok := cells[r]
+		if !ok {
+			return group, fmt.Errorf("row %d: missing totals row", r)
+		}
+		if row[0] == totalCountLabel {
+			count, err := readNumber(row[1])
+			if err != nil || count != uint64(len(group.Data)) {
+				return group, cellError(r, 1, row[1], fmt.Errorf("total count doesn't match %d data rows", len(group.Data)))
+			}
+			total, err := readNumber(row[amountColumn])
+			if err != nil || total != amount {
+				return group, cellError(r, amountColumn, row[amountColumn], fmt.Errorf("total amount doesn't match the data rows: %d", amount))
+			}
+			group.Trailer = types.Trailer{TotalCount: int(count), TotalAmount: total}
+			return group, nil
+		}
+
+		d, err := readData(r, row)
+		if err != nil {
+			return group, err
+		}
+		group.Data = append(group.Data, d)
+		amount += d.Amount
+	}
+}
+
+// readData returns the data record of a row
+func readData(r int, row map[int]string) (types.Data, error) {
+	d := types.Data{EdiPresent: row[12] == "Y"}
+	for _, c := range []struct {
+		column int
+		value  *string
+	}{{1, &d.RecipientBankName}, {3, &d.RecipientBranchName}, {7, &d.RecipientName}, {10, &d.Extra}} {
+		if err := kana.Validate(row[c.column]); err != nil {
+			return d, cellError(r, c.column, row[c.column], err)
+		}
+		*c.value = row[c.column]
+	}
+	var err error
+	for _, c := range []struct {
+		column int
+		length int
+		value  *string
+	}{{0, 4, &d.RecipientBankCode}, {2, 3, &d.RecipientBranchCode}, {6, 7, &d.RecipientAccountNumber}} {
+		if *c.value, err = readCode(row[c.column], c.length); err != nil {
+			return d, cellError(r, c.column, row[c.column], err)
+		}
+	}
+	for _, c := range []struct {
+		column int
+		length int
+		value  *string
+	}{{4, 4, &d.ExchangeOfficeCode}, {11, 1, &d.TransferCategory}} {
+		if *c.value, err = readOptionalCode(row[c.column], c.length); err != nil {
+			return d, cellError(r, c.column, row[c.column], err)
+		}
+	}
+	if d.RecipientAccountType, err = readAccountType(row[5]); err != nil {
+		return d, cellError(r, 5, row[5], err)
+	}
+	if d.Amount, err = readNumber(row[amountColumn]); err != nil {
+		return d, cellError(r, amountColumn, row[amountColumn], err)
+	}
+	switch newCode := row[9]; newCode {
+	case "", "0":
+		d.NewCode = types.CodeOther
+	case "1":
+		d.NewCode = types.CodeFirstTransfer
+	case "2":
+		d.NewCode = types.CodeUpdateTransfer
+	default:
+		return d, cellError(r, 9, newCode, errors.New("invalid new code"))
+	}
+	return d, nil
+}
+
+func cellError(row, column int, value string, err error) error {
+	return fmt.Errorf("cell %s%d, value '%s': %w", columnName(column), row, value, err)
+}
+
+// readCode returns a code of length digits, padding with zeros the codes Excel turned into numbers
+func readCode(value string, length int) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.Trim(value, "0123456789") != "" {
+		return value, fmt.Errorf("must be %d digits", length)
+	}
+	if len(value) < length {
+		value = strings.Repeat("0", length-len(value)) + value
+	}
+	if len(value) != length {
+		return value, fmt.Errorf("must be %d digits", length)
+	}
+	return value, nil
+}
+
+// readOptionalCode returns a code of length digits like readCode, or an empty string for a blank cell
+func readOptionalCode(value string, length int) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+	return readCode(value, length)
+}
+
+// readNumber parses a number cell, which Excel may write in exponent notation
+func readNumber(value string) (uint64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || f < 0 || f != math.Trunc(f) || f > 1e15 {
+		return 0, errors.New("invalid number")
+	}
+	return uint64(f), nil
+}
+
+func readAccountType(value string) (types.AccountType, error) {
+	if t := types.ParseAccountType(strings.TrimSpace(value)); t != types.AccountTypeUndefined {
+		return t, nil
+	}
+	return types.AccountTypeUndefined, errors.New("invalid account type")
+}
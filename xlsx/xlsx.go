@@ -0,0 +1,266 @@
+// Package xlsx writes header groups as Excel workbooks for review and reads them back,
+// with the standard library only.
+//
+// Every header group has a sheet: the header record in rows 1 and 2, the data records from row 5
+// under the labels of row 4, and a totals row after them. Codes are text cells so that Excel keeps
+// their leading zeros, and amounts are number cells.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/types"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	headerLabels = []string{
+		"種別コード", "コード区分", "振込依頼人コード", "振込依頼人名", "振込指定日",
+		"仕向金融機関番号", "仕向金融機関名", "仕向支店番号", "仕向支店名", "依頼人預金種目", "依頼人口座番号",
+	}
+	dataLabels = []string{
+		"被仕向金融機関番号", "被仕向金融機関名", "被仕向支店番号", "被仕向支店名", "手形交換所番号", "受取人預金種目",
+		"受取人口座番号", "受取人名", "振込金額", "新規コード", "顧客コード・EDI情報", "振込指定区分", "識別表示",
+	}
+)
+
+const (
+	totalCountLabel  = "合計件数"
+	totalAmountLabel = "合計金額"
+	dataRow          = 5 // first row of the data records
+	amountColumn     = 8 // 0-based column of 振込金額 in the data rows
+)
+
+// cell styles of styles.xml
+const (
+	styleDefault = iota
+	styleText    // text format (@), so that codes stay text when edited
+	styleAmount  // #,##0
+	styleLabel   // bold
+)
+
+// cell is a cell of a sheet, a number if number is set
+type cell struct {
+	text    string
+	number  *uint64
+	formula string
+	style   int
+}
+
+func text(s string) cell {
+	return cell{text: s, style: styleText}
+}
+
+func label(s string) cell {
+	return cell{text: s, style: styleLabel}
+}
+
+func number(n uint64, style int) cell {
+	return cell{number: &n, style: style}
+}
+
+// Write writes groups as a workbook with a sheet per header group, named after the position of the group.
+// A zero Trailer is computed from the data records, any other Trailer must match them.
+func Write(w io.Writer, groups []types.Group) error {
+	if len(groups) == 0 {
+		return errors.New("no header groups")
+	}
+	sheets := make([][][]cell, len(groups))
+	for i, group := range groups {
+		sheet, err := groupSheet(group)
+		if err != nil {
+			return fmt.Errorf("header group %d: %w", i+1, err)
+		}
+		sheets[i] = sheet
+	}
+
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes(len(sheets))},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook(len(sheets))},
+		{"xl/_rels/workbook.xml.rels", workbookRels(len(sheets))},
+		{"xl/styles.xml", styles},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheet(sheet)})
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// groupSheet returns the rows of the sheet of a header group
+func groupSheet(group types.Group) ([][]cell, error) {
+	var count int
+	var amount uint64
+	for _, data := range group.Data {
+		count++
+		amount += data.Amount
+	}
+	if group.Trailer != (types.Trailer{}) && (group.Trailer.TotalCount != count || group.Trailer.TotalAmount != amount) {
+		return nil, fmt.Errorf("trailer totals %d/%d don't match the data records: %d/%d",
+			group.Trailer.TotalCount, group.Trailer.TotalAmount, count, amount)
+	}
+
+	h := group.Header
+	categoryCode := h.RawCategoryCode
+	if categoryCode == "" {
+		categoryCode = h.CategoryCode.Code()
+	}
+	// The sender account is optional, and blank in headers parsed from short records
+	senderAccountType := ""
+	if h.SenderAccountType != types.AccountTypeUndefined {
+		senderAccountType = strconv.Itoa(int(h.SenderAccountType))
+	}
+	rows := [][]cell{
+		labels(headerLabels),
+		{
+			text(categoryCode), text(h.EncodingType), text(h.SenderCode), text(trim(h.SenderName)), text(h.TransferDate),
+			text(h.SenderBankCode), text(trim(h.SenderBankName)), text(h.SenderBranchCode), text(trim(h.SenderBranchName)),
+			text(senderAccountType), text(strings.TrimSpace(h.SenderAccountNumber)),
+		},
+		nil,
+		labels(dataLabels),
+	}
+	for _, d := range group.Data {
+		ediPresent := ""
+		if d.EdiPresent {
+			ediPresent = "Y"
+		}
+		rows = append(rows, []cell{
+			text(d.RecipientBankCode), text(trim(d.RecipientBankName)), text(d.RecipientBranchCode), text(trim(d.RecipientBranchName)),
+			text(strings.TrimSpace(d.ExchangeOfficeCode)), text(strconv.Itoa(int(d.RecipientAccountType))), text(d.RecipientAccountNumber),
+			text(trim(d.RecipientName)), number(d.Amount, styleAmount), text(strconv.Itoa(int(d.NewCode))),
+			text(trim(d.Extra)), text(strings.TrimSpace(d.TransferCategory)), text(ediPresent),
+		})
+	}
+
+	totals := make([]cell, amountColumn+1)
+	totals[0] = label(totalCountLabel)
+	totals[1] = number(uint64(count), styleDefault)
+	totals[amountColumn-1] = label(totalAmountLabel)
+	totals[amountColumn] = number(amount, styleAmount)
+	if count > 0 {
+		totals[1].formula = fmt.Sprintf("COUNT(%s%d:%s%d)", columnName(amountColumn), dataRow, columnName(amountColumn), dataRow+count-1)
+		totals[amountColumn].formula = fmt.Sprintf("SUM(%s%d:%s%d)", columnName(amountColumn), dataRow, columnName(amountColumn), dataRow+count-1)
+	}
+	return append(rows, totals), nil
+}
+
+func labels(names []string) []cell {
+	row := make([]cell, len(names))
+	for i, name := range names {
+		row[i] = label(name)
+	}
+	return row
+}
+
+// trim removes the padding of a name
+func trim(name string) string {
+	return strings.TrimRight(name, " ")
+}
+
+// columnName returns the letters of a 0-based column, such as A for 0 and AA for 26
+func columnName(column int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name
+}
+
+func worksheet(rows [][]cell) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			if cell.text == "" && cell.number == nil {
+				continue
+			}
+			ref := fmt.Sprintf("%s%d", columnName(c), r+1)
+			if cell.number != nil {
+				fmt.Fprintf(&b, `<c r="%s" s="%d">`, ref, cell.style)
+				if cell.formula != "" {
+					fmt.Fprintf(&b, `<f>%s</f>`, cell.formula)
+				}
+				fmt.Fprintf(&b, `<v>%d</v></c>`, *cell.number)
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, cell.style)
+			_ = xml.EscapeText(&b, []byte(cell.text))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+func contentTypes(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func workbook(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<sheet name="Group %d" sheetId="%d" r:id="rId%d"/>`, i, i, i)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func workbookRels(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// styles has the cell formats of styleDefault, styleText, styleAmount and styleLabel, in that order
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="49" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs></styleSheet>`
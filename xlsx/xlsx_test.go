@@ -0,0 +1,130 @@
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/Kyash/zengin-go"
+	"github.com/Kyash/zengin-go/internal/zengintest"
+	"github.com/Kyash/zengin-go/types"
+	"github.com/Kyash/zengin-go/xlsx"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXLSX(t *testing.T) {
+	input := zengintest.File(
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999                 ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y       ",
+		"8000002000000000004                                                                                                     ",
+		"17110110999999ｶ)ｹﾝｼﾝｼﾖｳｼﾞ                             02242606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    010ﾎﾝﾃﾝ           20999999                 ",
+		"8000000000000000000                                                                                                     ",
+		"9                                                                                                                       ",
+	)
+	groups, err := zengin.ParseGroups(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var workbook bytes.Buffer
+	if err := xlsx.Write(&workbook, groups); err != nil {
+		t.Fatal(err)
+	}
+	read, err := xlsx.Read(bytes.NewReader(workbook.Bytes()), int64(workbook.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := zengin.Write(&output, read, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != input {
+		t.Fatalf("expected %q, got %q", input, output.String())
+	}
+
+	// Excel turns codes into numbers when they are edited, and keeps strings apart
+	edit := func(old, new string) *bytes.Reader {
+		archive, err := zip.NewReader(bytes.NewReader(workbook.Bytes()), int64(workbook.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var edited bytes.Buffer
+		writer := zip.NewWriter(&edited)
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Name == "xl/worksheets/sheet1.xml" {
+				content = bytes.Replace(content, []byte(old), []byte(new), 1)
+			}
+			part, err := writer.Create(file.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write(content)
+		}
+		writer.Close()
+		return bytes.NewReader(edited.Bytes())
+	}
+	edited := edit(`<c r="C5" s="1" t="inlineStr"><is><t xml:space="preserve">020</t></is></c>`, `<c r="C5"><v>20</v></c>`)
+	if read, err := xlsx.Read(edited, edited.Size()); err != nil || read[0].Data[0].RecipientBranchCode != "020" {
+		t.Fatalf("expected branch code 020, got %v", err)
+	}
+	edited = edit(`<v>1</v>`, `<v>2</v>`)
+	if _, err := xlsx.Read(edited, edited.Size()); err == nil || !strings.Contains(err.Error(), "total amount") {
+		t.Fatalf("expected error for an amount that doesn't match the totals row, got %v", err)
+	}
+
+	// Cells are checked as the fields of the records
+	edited = edit(`ｹﾝｼﾝ ｼﾖｳｼﾞ`, `けんしん しょうじ`)
+	if _, err := xlsx.Read(edited, edited.Size()); err == nil || !strings.Contains(err.Error(), "cell H5") {
+		t.Fatalf("expected error for a name out of the Zengin character set, got %v", err)
+	}
+	edited = edit(`<c r="M6"`, `<c r="L6" t="inlineStr"><is><t>X</t></is></c><c r="M6"`)
+	if _, err := xlsx.Read(edited, edited.Size()); err == nil || !strings.Contains(err.Error(), "cell L6") {
+		t.Fatalf("expected error for a transfer category that isn't a digit, got %v", err)
+	}
+
+	groups[0].Trailer.TotalAmount = 5
+	if err := xlsx.Write(&workbook, groups); err == nil {
+		t.Fatal("expected error for mismatching trailer, got nil")
+	}
+}
+
+func TestBlankSenderAccount(t *testing.T) {
+	input := zengintest.File(
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010                                        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        ",
+		"8000001000000000001                                                                                                     ",
+		"9                                                                                                                       ",
+	)
+	groups, err := zengin.ParseGroups(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var workbook bytes.Buffer
+	if err := xlsx.Write(&workbook, groups); err != nil {
+		t.Fatal(err)
+	}
+	read, err := xlsx.Read(bytes.NewReader(workbook.Bytes()), int64(workbook.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := read[0].Header; header.SenderAccountType != types.AccountTypeUndefined || header.SenderAccountNumber != "" {
+		t.Fatalf("expected a blank sender account, got %+v", header)
+	}
+	var output bytes.Buffer
+	if err := zengin.Write(&output, read, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != input {
+		t.Fatalf("expected %q, got %q", input, output.String())
+	}
+}
@@ -1,11 +1,131 @@
 package zengin
 
 import (
+	"errors"
 	zengin "github.com/Kyash/zengin-go/internal"
 	"github.com/Kyash/zengin-go/types"
+	"golang.org/x/text/encoding"
+	"io"
+	"log/slog"
+	"time"
 )
 
-// Parse Zengin format file and return rows with all fields
+// Option configures ParseWithOptions
+type Option func(*zengin.Config)
+
+// WithEncoding reads the file in the given encoding instead of detecting it:
+// types.EncodingShiftJIS, types.EncodingUTF8 or an encoding returned by RegisterEncoding
+func WithEncoding(encoding types.Encoding) Option {
+	return func(config *zengin.Config) {
+		config.Encoding = encoding
+	}
+}
+
+// WithRecordFormat reads records separated by line breaks (types.RecordFormatLines)
+// or of fixed length without line breaks (types.RecordFormatFixed) instead of detecting it:
+// 120 bytes in Shift-JIS, 120 characters in UTF-8
+func WithRecordFormat(format types.RecordFormat) Option {
+	return func(config *zengin.Config) {
+		config.RecordFormat = format
+	}
+}
+
+// WithLineEnding requires (types.LineEndingCRLF) or forbids (types.LineEndingLF) CRLF between records
+func WithLineEnding(lineEnding types.LineEnding) Option {
+	return func(config *zengin.Config) {
+		config.LineEnding = lineEnding
+	}
+}
+
+// WithStrictRecords reports lines that are not Zengin records, such as invisible characters
+// left by some programs, instead of skipping them
+func WithStrictRecords(strict bool) Option {
+	return func(config *zengin.Config) {
+		config.StrictRecords = strict
+	}
+}
+
+// WithLenient keeps parsing after invalid records and returns every error in ParseResult.Diagnostics.
+// Invalid data records are left out and the rest of their header group is kept.
+func WithLenient(lenient bool) Option {
+	return func(config *zengin.Config) {
+		config.Lenient = lenient
+	}
+}
+
+// WithLogger sends debug messages about encoding detection and skipped lines to logger,
+// and a warning for every diagnostic in lenient mode. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(config *zengin.Config) {
+		config.Logger = logger
+	}
+}
+
+// WithBankDirectory checks bank and branch codes against directory, such as a list loaded by bankdir.LoadJSON:
+// unknown codes are errors, blank bank and branch names are filled in,
+// and names that don't match their code are returned in ParseResult.Warnings.
+// Unknown bank codes are only warnings with a types.PartialDirectory such as bankdir.MajorBanks().
+func WithBankDirectory(directory types.BankDirectory) Option {
+	return func(config *zengin.Config) {
+		config.BankDirectory = directory
+	}
+}
+
+// WithReferenceDate resolves the transfer date of every header to the first such date on or after date,
+// see types.Header.ResolvedTransferDate
+func WithReferenceDate(date time.Time) Option {
+	return WithClock(func() time.Time { return date })
+}
+
+// WithClock resolves the transfer date of every header to the first such date on or after the time returned by clock
+func WithClock(clock func() time.Time) Option {
+	return func(config *zengin.Config) {
+		config.Clock = clock
+	}
+}
+
+// WithCalendar rejects transfer dates on which banks are closed, such as calendar.Japan(),
+// with an error wrapping types.ErrNotBusinessDay. It needs WithReferenceDate or WithClock to resolve the dates,
+// otherwise parsing returns an error wrapping types.ErrNoReferenceDate.
+func WithCalendar(calendar types.Calendar) Option {
+	return func(config *zengin.Config) {
+		config.Calendar = calendar
+	}
+}
+
+func newConfig(options []Option) zengin.Config {
+	var config zengin.Config
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// RegisterEncoding makes a single-byte character encoding, such as an EBCDIC code page,
+// available to WithEncoding and NewEncoder, and returns its identifier.
+// Lines end with the line feed or NL of the encoding. Stateful encodings such as ISO-2022-JP are not supported,
+// and files in the JIS コード区分 (8-bit JIS X 0201) are read with types.EncodingShiftJIS.
+func RegisterEncoding(e encoding.Encoding) types.Encoding {
+	return zengin.RegisterEncoding(e)
+}
+
+// Decoder reads Zengin format files one transfer at a time, see NewDecoder
+type Decoder = zengin.Decoder
+
+// Encoder writes Zengin format files, see NewEncoder
+type Encoder = zengin.Encoder
+
+// TableWriter writes transfers as CSV or TSV, see NewCSVWriter
+type TableWriter = zengin.TableWriter
+
+// CSVReader reads data records from a CSV file, see NewCSVReader
+type CSVReader = zengin.CSVReader
+
+// JSONEncoder writes transfers and header groups as JSON, see NewJSONEncoder
+type JSONEncoder = zengin.JSONEncoder
+
+// Parse Zengin format file and return rows with all fields.
+// A valid file without data records returns types.ErrNoTransfers.
 func Parse(reader zengin.Reader) ([]types.Transfer, error) {
 	transfers, err := zengin.Parse(reader)
 	if err != nil {
@@ -15,13 +135,231 @@ func Parse(reader zengin.Reader) ([]types.Transfer, error) {
 	return transfers, nil
 }
 
+// ParseWithOptions
+// Parse Zengin format file with the given options.
+// Return the transfers together with the encoding of the file, and every error found in lenient mode.
+func ParseWithOptions(reader zengin.Reader, options ...Option) (types.ParseResult, error) {
+	return zengin.ParseWithConfig(reader, newConfig(options))
+}
+
+// ParseLenient
+// Parse Zengin format file without stopping at invalid records.
+// Return the transfers of every valid data record together with all the errors found in the file.
+// Header groups with an invalid header or trailer record are left out.
+// Trailer totals that don't match the data records, such as after an invalid data record is left out,
+// are reported without dropping the transfers.
+func ParseLenient(reader zengin.Reader) (types.ParseResult, error) {
+	return ParseWithOptions(reader, WithLenient(true))
+}
+
+// ParseGroups
+// Parse Zengin format file and return every header record with its data records and trailer record,
+// exactly as they are in the file. Trailer totals are not checked.
+func ParseGroups(reader zengin.Reader) ([]types.Group, error) {
+	return zengin.ParseGroups(reader)
+}
+
+// ParseFile
+// Parse Zengin format file like ParseGroups and return its header groups together with its end record
+// and line endings, which WriteFile writes back byte for byte
+func ParseFile(reader zengin.Reader) (types.File, error) {
+	return zengin.ParseFile(reader)
+}
+
+// ParseDebit
+// Parse a 口座振替 (direct debit, 種別コード 91) file with the given options: a request file,
+// or a result file returned by the bank with a types.ResultCode in every data record.
+// Trailer totals are checked, the result totals only in result files.
+func ParseDebit(reader zengin.Reader, options ...Option) (types.DebitResult, error) {
+	return zengin.ParseDebitWithConfig(reader, newConfig(options))
+}
+
+// ParseNotification
+// Parse a 振込入金通知 (incoming credit notification, 種別コード 01) file with the given options.
+// Its records are 200 bytes long. Trailer totals are checked, with the cancelled credits counted apart.
+func ParseNotification(reader zengin.Reader, options ...Option) (types.NotificationResult, error) {
+	return zengin.ParseNotificationWithConfig(reader, newConfig(options))
+}
+
+// ParseStatement
+// Parse a 入出金取引明細 (statement of deposits and withdrawals, 種別コード 03) file with the given options,
+// with a header group for every account. Its records are 200 bytes long.
+// Trailer totals of deposits and withdrawals are checked, and so is the balance after the transactions.
+// 預金残高報告 (balance report) files are out of scope and fail with an error on CategoryCode.
+func ParseStatement(reader zengin.Reader, options ...Option) (types.StatementResult, error) {
+	return zengin.ParseStatementWithConfig(reader, newConfig(options))
+}
+
+// NewDecoder
+// Return a Decoder reading transfers from a Zengin format file one at a time, with bounded memory.
+// Call Next until it returns io.EOF. Trailer totals are checked as each header group ends
+// and a mismatch is returned as a *types.GroupError, after the transfers of that group.
+func NewDecoder(reader zengin.Reader) (*Decoder, error) {
+	return zengin.NewDecoder(reader)
+}
+
+// NewEncoder
+// Return an Encoder writing 120-byte records (120 characters in UTF-8) to writer.
+// Call Encode for every header group and Close to write the end record.
+func NewEncoder(writer io.Writer, encoding types.Encoding) *Encoder {
+	return zengin.NewEncoder(writer, encoding)
+}
+
+// Write
+// Write groups as a Zengin format file with CRLF line endings and a blank end record.
+// A zero Trailer is computed from the data records, any other Trailer must match them.
+// Groups returned by ParseGroups are written back byte for byte if the file was written so, see WriteFile.
+func Write(writer io.Writer, groups []types.Group, encoding types.Encoding) error {
+	return WriteFile(writer, types.File{Groups: groups, LineEnding: "\r\n", EndLineEnding: "\r\n"}, encoding)
+}
+
+// WriteFile
+// Write the header groups of file as Write does, with its end record and line endings.
+// A file returned by ParseFile is written back byte for byte, as long as its records are 120 bytes long.
+func WriteFile(writer io.Writer, file types.File, encoding types.Encoding) error {
+	encoder := zengin.NewEncoder(writer, encoding)
+	encoder.SetLineEnding(file.LineEnding)
+	encoder.SetEnd(file.End, file.EndLineEnding)
+	for _, group := range file.Groups {
+		var trailer *types.Trailer
+		if group.Trailer != (types.Trailer{}) {
+			trailer = &group.Trailer
+		}
+		if err := encoder.Encode(group.Header, group.Data, trailer); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// WriteDebit
+// Write groups as a 口座振替 file with CRLF line endings.
+// A zero Trailer is computed from the data records as in a request file, any other Trailer must match them.
+func WriteDebit(writer io.Writer, groups []types.DebitGroup, encoding types.Encoding) error {
+	encoder := zengin.NewEncoder(writer, encoding)
+	for _, group := range groups {
+		var trailer *types.DebitTrailer
+		if group.Trailer != (types.DebitTrailer{}) {
+			trailer = &group.Trailer
+		}
+		if err := encoder.EncodeDebit(group.Header, group.Data, trailer); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// NewJSONEncoder
+// Return a JSONEncoder writing a JSON array of transfers or header groups to writer, ended by Close.
+// Keys are the snake_case names of the fields in types, such as recipient_account_type, codes are zero-padded strings
+// and enums are written as strings such as "regular", or 普通 with SetJapanese.
+func NewJSONEncoder(writer io.Writer) *JSONEncoder {
+	return zengin.NewJSONEncoder(writer, false)
+}
+
+// NewNDJSONEncoder
+// Return a JSONEncoder writing newline-delimited JSON to writer, one transfer or header group per line,
+// with the same keys as NewJSONEncoder
+func NewNDJSONEncoder(writer io.Writer) *JSONEncoder {
+	return zengin.NewJSONEncoder(writer, true)
+}
+
+// ToJSON
+// Parse Zengin format file and write its transfers with their trailer totals to writer as a JSON array,
+// see NewJSONEncoder. Nothing is written if the file is invalid.
+func ToJSON(reader zengin.Reader, writer io.Writer) error {
+	transfers, err := zengin.Parse(reader)
+	if err != nil && !errors.Is(err, types.ErrNoTransfers) {
+		return err
+	}
+
+	encoder := zengin.NewJSONEncoder(writer, false)
+	for _, transfer := range transfers {
+		if err := encoder.EncodeTransfer(transfer); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// ToNDJSON
+// Parse Zengin format file one transfer at a time and write every transfer to writer as a line of JSON,
+// so that large files are streamed with bounded memory. Transfers have no trailer totals, as in NewDecoder.
+// A trailer that doesn't match its data records is returned as a *types.GroupError after its transfers are written.
+func ToNDJSON(reader zengin.Reader, writer io.Writer) error {
+	encoder := zengin.NewJSONEncoder(writer, true)
+	decoder, err := zengin.NewDecoder(reader)
+	if err != nil {
+		return err
+	}
+	for {
+		transfer, err := decoder.Next()
+		if err == io.EOF {
+			return encoder.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if err := encoder.EncodeTransfer(transfer); err != nil {
+			return err
+		}
+	}
+}
+
+// NewCSVWriter
+// Return a TableWriter writing transfers to writer as comma-separated values with a header row,
+// by default in UTF-8 with the columns of ToCSV. Select other columns with SetColumns, see Columns.
+func NewCSVWriter(writer io.Writer) *TableWriter {
+	return zengin.NewTableWriter(writer, ',')
+}
+
+// NewTSVWriter
+// Return a TableWriter writing transfers to writer as tab-separated values, see NewCSVWriter
+func NewTSVWriter(writer io.Writer) *TableWriter {
+	return zengin.NewTableWriter(writer, '\t')
+}
+
+// Columns
+// Return the names of every column a TableWriter can write: the fields of types.Header, types.Data
+// and types.Trailer but the record types and dummies, in record order
+func Columns() []string {
+	return zengin.Columns()
+}
+
+// NewCSVReader
+// Return a CSVReader reading data records from a CSV file with a header row, such as a spreadsheet export.
+// Columns are found by the headers written by NewCSVWriter and ToCSVJa or set with SetColumn.
+func NewCSVReader(reader io.Reader) *CSVReader {
+	return zengin.NewCSVReader(reader)
+}
+
+// ImportCSV
+// Read data records from a CSV file as NewCSVReader does and write them to writer as a 総合振込 file
+// with the given header, followed by a trailer with the computed totals and an end record.
+// A header without a category is written as 総合振込. Errors of every row are returned as *types.RowError.
+func ImportCSV(reader io.Reader, header types.Header, writer io.Writer, encoding types.Encoding) error {
+	data, err := zengin.NewCSVReader(reader).Read()
+	if err != nil {
+		return err
+	}
+	if header.CategoryCode == types.CategoryCodeUndefined {
+		header.CategoryCode = types.CategoryCodeCombination
+	}
+
+	encoder := zengin.NewEncoder(writer, encoding)
+	if err := encoder.Encode(header, data, nil); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
 // ToCSV
 // Parse Zengin format file and return a csv like table with field names as below
-// SenderName,TransferDate,BankCode,BranchCode,AccountType,AccountNumber,AccountName,Amount
+// SenderName,RecipientBankCode,RecipientBranchCode,RecipientAccountType,RecipientAccountNumber,RecipientName,Amount
 func ToCSV(reader zengin.Reader) ([][]string, error) {
 
 	transfers, err := zengin.Parse(reader)
-	if err != nil {
+	if err != nil && !errors.Is(err, types.ErrNoTransfers) {
 		return nil, err
 	}
 
@@ -30,11 +368,11 @@ func ToCSV(reader zengin.Reader) ([][]string, error) {
 
 // ToCSVJa
 // Parse Zengin format file and return a csv like table with field names as below
-// 振込名義人,振込日,金融機関コード,支店コード,科目,口座番号,口座名義人,金額
+// 振込名義人,金融機関コード,支店コード,科目,口座番号,口座名義人,金額
 func ToCSVJa(reader zengin.Reader) ([][]string, error) {
 
 	transfers, err := zengin.Parse(reader)
-	if err != nil {
+	if err != nil && !errors.Is(err, types.ErrNoTransfers) {
 		return nil, err
 	}
 
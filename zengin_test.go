@@ -1,9 +1,21 @@
 package zengin
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Kyash/zengin-go/bankdir"
+	"github.com/Kyash/zengin-go/calendar"
+	"github.com/Kyash/zengin-go/internal/zengintest"
+	"github.com/Kyash/zengin-go/types"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type test struct {
@@ -172,3 +184,1040 @@ b`, [][]string{
 		})
 	}
 }
+
+func TestWriteRoundTrip(t *testing.T) {
+	input := zengintest.File(
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999                 ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y       ",
+		"8000002000000000004                                                                                                     ",
+		"9                                                                                                                       ",
+	)
+
+	for _, encoding := range []types.Encoding{types.EncodingUTF8, types.EncodingShiftJIS} {
+		file := input
+		if encoding == types.EncodingShiftJIS {
+			file = zengintest.ShiftJIS(t, input)
+		}
+
+		groups, err := ParseGroups(strings.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output bytes.Buffer
+		if err := Write(&output, groups, encoding); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != file {
+			t.Fatalf("expected %q, got %q", file, output.String())
+		}
+
+		// A zero trailer is computed from the data records
+		groups[0].Trailer = types.Trailer{}
+		output.Reset()
+		if err := Write(&output, groups, encoding); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(output.String(), "8000002000000000004") {
+			t.Fatalf("expected computed trailer, got %q", output.String())
+		}
+
+		groups[0].Trailer.TotalAmount = 5
+		if err := Write(&output, groups, encoding); err == nil {
+			t.Fatal("expected error for mismatching trailer, got nil")
+		}
+	}
+
+	// Blank account fields, LF line endings and an end record with a dummy are written back as parsed
+	input = strings.Join([]string{
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010                                        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        ",
+		"8000001000000000001                                                                                                     ",
+		"9END" + strings.Repeat(" ", 116),
+	}, "\n")
+	file, err := ParseFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Groups[0].Header.SenderAccountType != types.AccountTypeUndefined || file.End.Dummy[:3] != "END" ||
+		file.LineEnding != "\n" || file.EndLineEnding != "" {
+		t.Fatalf("unexpected file: %+v", file)
+	}
+	var output bytes.Buffer
+	if err := WriteFile(&output, file, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != input {
+		t.Fatalf("expected %q, got %q", input, output.String())
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              29999999ｹﾝｼﾝ ﾊﾅｺ                      00000000020                    0
+8000002000000000009
+12110110999999ｹﾝｼﾝ ﾀﾛ                                 01142606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y
+8000001000000000003
+9`
+
+	decoder, err := NewDecoder(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var groupErrors []*types.GroupError
+	for {
+		transfer, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		var groupError *types.GroupError
+		if errors.As(err, &groupError) {
+			groupErrors = append(groupErrors, groupError)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, transfer.RecipientName)
+	}
+
+	expected := []string{"ｹﾝｼﾝ ｼﾖｳｼﾞ", "ｹﾝｼﾝ ﾊﾅｺ", "ｹﾝｼﾝ ｼﾞﾛｳ"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	if len(groupErrors) != 1 || groupErrors[0].Group != 1 || groupErrors[0].Amount != 3 {
+		t.Fatalf("expected one amount mismatch in group 1, got %v", groupErrors)
+	}
+}
+
+func TestParseAllFields(t *testing.T) {
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    010ﾎﾝﾃﾝ           20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y
+8000001000000000003
+9`
+
+	transfers, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := types.Transfer{
+		Header: types.Header{
+			RecordType:          "1",
+			CategoryCode:        types.CategoryCodeCombination,
+			RawCategoryCode:     "21",
+			EncodingType:        "1",
+			SenderCode:          "0110999999",
+			SenderName:          "ｹﾝｼﾝ ﾀﾛｳ",
+			TransferDate:        "0224",
+			SenderBankCode:      "2606",
+			SenderBankName:      "ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ",
+			SenderBranchCode:    "010",
+			SenderBranchName:    "ﾎﾝﾃﾝ",
+			SenderAccountType:   types.AccountTypeChecking,
+			SenderAccountNumber: "0999999",
+		},
+		Data: types.Data{
+			RecordType:             "2",
+			RecipientBankCode:      "2606",
+			RecipientBankName:      "ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ",
+			RecipientBranchCode:    "030",
+			RecipientBranchName:    "ｻﾝﾉﾐﾔ",
+			ExchangeOfficeCode:     "    ",
+			RecipientAccountType:   types.AccountTypeRegular,
+			RecipientAccountNumber: "1234567",
+			RecipientName:          "ｹﾝｼﾝ ｼﾞﾛｳ",
+			Amount:                 3,
+			NewCode:                types.CodeOther,
+			Extra:                  "ﾏｲﾂｷﾌﾞﾝ             ",
+			TransferCategory:       "0",
+			EdiPresent:             true,
+		},
+		Trailer: types.Trailer{
+			RecordType:  "8",
+			TotalCount:  1,
+			TotalAmount: 3,
+		},
+	}
+	if len(transfers) != 1 || !reflect.DeepEqual(transfers[0], expected) {
+		t.Fatalf("expected %+v, got %+v", expected, transfers)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              1A12345 ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030                    0
+8000002000000000004
+9`
+
+	_, err := Parse(strings.NewReader(input))
+	var parseError *types.ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected *types.ParseError, got %v", err)
+	}
+	expected := types.ParseError{
+		Record:     3,
+		RecordType: "2",
+		Field:      "RecipientAccountNumber",
+		FieldJa:    "受取人口座番号",
+		Start:      43,
+		End:        50,
+		Value:      "A12345 ",
+		Err:        parseError.Err,
+	}
+	if *parseError != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *parseError)
+	}
+	if !strings.HasPrefix(err.Error(), "line 3, field 受取人口座番号 RecipientAccountNumber (cols 44-50), value 'A12345 '") {
+		t.Fatalf("unexpected message: %v", err)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              39876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              29999999ｹﾝｼﾝ ﾊﾅｺ                      00000000X20                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              21111111ｹﾝｼﾝ ｻﾌﾞﾛｳ                    00000000040                    0
+8000003000000000070
+12110110999999ｹﾝｼﾝ ﾀﾛ                                 01142606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y
+8000001000000000004
+12110110999999ｹﾝｼﾝ ﾀﾛ                                 0114X606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾛｳ                      00000000030                    0
+8000001000000000030
+9`
+
+	result, err := ParseLenient(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, transfer := range result.Transfers {
+		names = append(names, transfer.RecipientName)
+	}
+	// The valid data record of the first group is kept, the group with an invalid header is not
+	if !reflect.DeepEqual(names, []string{"ｹﾝｼﾝ ｻﾌﾞﾛｳ", "ｹﾝｼﾝ ｼﾞﾛｳ"}) {
+		t.Fatalf("expected the valid transfers of the first two groups, got %v", names)
+	}
+	var fields []string
+	for _, diagnostic := range result.Diagnostics {
+		fields = append(fields, fmt.Sprintf("%d:%s", diagnostic.Record, diagnostic.Field))
+	}
+	expected := []string{"2:RecipientAccountType", "3:Amount", "5:TotalCount", "8:TotalAmount", "9:SenderBankCode"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expected %v, got %v", expected, fields)
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	input := "12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999\r\n" +
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0\r\n" +
+		"8000001000000000001\n" +
+		"\x00\r\n" +
+		"9"
+
+	tests := []struct {
+		name          string
+		options       []Option
+		expectedError bool
+	}{
+		{"Default", nil, false},
+		{"ForcedEncoding", []Option{WithEncoding(types.EncodingUTF8)}, false},
+		{"WrongEncoding", []Option{WithEncoding(types.EncodingShiftJIS)}, true},
+		{"RequireCRLF", []Option{WithLineEnding(types.LineEndingCRLF)}, true},
+		{"ForbidCRLF", []Option{WithLineEnding(types.LineEndingLF)}, true},
+		{"StrictRecords", []Option{WithStrictRecords(true)}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ParseWithOptions(strings.NewReader(input), test.options...)
+			if test.expectedError {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Encoding != types.EncodingUTF8 || len(result.Transfers) != 1 {
+				t.Fatalf("expected one UTF-8 transfer, got %+v", result)
+			}
+		})
+	}
+
+	crlf := strings.Replace(input, "0001\n", "0001\r\n", 1)
+	if _, err := ParseWithOptions(strings.NewReader(crlf), WithLineEnding(types.LineEndingCRLF)); err != nil {
+		t.Fatalf("expected the last record to need no line ending, got %v", err)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("disk on fire")
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(failingReader{}); !errors.Is(err, types.ErrReadFailure) {
+		t.Fatalf("expected ErrReadFailure, got %v", err)
+	}
+
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999
+8000000000000000000
+9`
+	if _, err := Parse(strings.NewReader(input)); !errors.Is(err, types.ErrNoTransfers) {
+		t.Fatalf("expected ErrNoTransfers, got %v", err)
+	}
+}
+
+func TestFixedLengthRecords(t *testing.T) {
+	groups := []types.Group{{
+		Header: types.Header{
+			CategoryCode:        types.CategoryCodeCombination,
+			SenderCode:          "0110999999",
+			SenderName:          "KENSHIN TARO",
+			TransferDate:        "0224",
+			SenderBankCode:      "2606",
+			SenderBranchCode:    "010",
+			SenderAccountType:   types.AccountTypeChecking,
+			SenderAccountNumber: "0999999",
+		},
+		Data: []types.Data{{
+			RecipientBankCode:      "2606",
+			RecipientBranchCode:    "020",
+			RecipientAccountType:   types.AccountTypeRegular,
+			RecipientAccountNumber: "9876543",
+			RecipientName:          "KENSHIN SHOJI",
+			Amount:                 1,
+		}},
+	}}
+	ebcdic := RegisterEncoding(charmap.CodePage037)
+
+	for _, encoding := range []types.Encoding{types.EncodingUTF8, types.EncodingShiftJIS, ebcdic} {
+		var file bytes.Buffer
+		encoder := NewEncoder(&file, encoding)
+		encoder.SetLineEnding("")
+		if err := encoder.Encode(groups[0].Header, groups[0].Data, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := encoder.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// ASCII-only files can't be told apart from Shift-JIS, the record format is detected
+		options := []Option{WithEncoding(encoding)}
+		if encoding == ebcdic {
+			options = append(options, WithRecordFormat(types.RecordFormatFixed))
+		}
+		result, err := ParseWithOptions(&file, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Encoding != encoding || len(result.Transfers) != 1 || result.Transfers[0].RecipientName != "KENSHIN SHOJI" {
+			t.Fatalf("expected one transfer in encoding %d, got %+v", encoding, result)
+		}
+	}
+
+	// Lines of EBCDIC files end with its own line feed (0x25) or NL (0x15)
+	var file bytes.Buffer
+	if err := Write(&file, groups, ebcdic); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(file.Bytes(), []byte{0x0d, 0x25}) {
+		t.Fatalf("expected EBCDIC line endings, got % x", file.Bytes())
+	}
+	for _, lineEnding := range []types.LineEnding{types.LineEndingCRLF, types.LineEndingAny} {
+		result, err := ParseWithOptions(bytes.NewReader(file.Bytes()), WithEncoding(ebcdic), WithLineEnding(lineEnding))
+		if err != nil || len(result.Transfers) != 1 {
+			t.Fatalf("expected one transfer from EBCDIC lines, got %+v, %v", result, err)
+		}
+	}
+	nl := bytes.ReplaceAll(file.Bytes(), []byte{0x0d, 0x25}, []byte{0x15})
+	if result, err := ParseWithOptions(bytes.NewReader(nl), WithEncoding(ebcdic)); err != nil || len(result.Transfers) != 1 {
+		t.Fatalf("expected one transfer from EBCDIC lines ending with NL, got %+v, %v", result, err)
+	}
+
+	// Fixed-length records have no line ending, which only the last record may lack with CRLF
+	file.Reset()
+	encoder := NewEncoder(&file, types.EncodingUTF8)
+	encoder.SetLineEnding("")
+	if err := encoder.Encode(groups[0].Header, groups[0].Data, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseWithOptions(&file, WithLineEnding(types.LineEndingCRLF)); err == nil {
+		t.Fatal("expected error for fixed-length records with CRLF required, got nil")
+	}
+}
+
+func TestShiftJISFields(t *testing.T) {
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543%s00000000010                    0
+8000001000000000010
+9`
+
+	tests := []struct {
+		name          string
+		recipientName string
+		invalid       rune
+	}{
+		// A full-width character takes two bytes, so the fields after it stay in place
+		{"full-width", "検ｼﾝ ｼﾖｳｼﾞ                    ", '検'},
+		{"lower case", "Kenshin Shoji                 ", 'e'},
+		{"small kana", "ｹﾝｼﾝ ｼｮｳｼﾞ                    ", 'ｮ'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := zengintest.ShiftJIS(t, fmt.Sprintf(input, tt.recipientName))
+			_, err := Parse(strings.NewReader(file))
+			var parseError *types.ParseError
+			if !errors.As(err, &parseError) {
+				t.Fatalf("expected *types.ParseError, got %v", err)
+			}
+			if parseError.Field != "RecipientName" || parseError.Start != 50 || parseError.End != 80 ||
+				!strings.Contains(err.Error(), fmt.Sprintf("%q", tt.invalid)) {
+				t.Fatalf("expected error on RecipientName about %q, got %v", tt.invalid, err)
+			}
+		})
+	}
+}
+
+func TestDebit(t *testing.T) {
+	group := types.DebitGroup{
+		Header: types.DebitHeader{
+			ConsignorCode: "1234567890",
+			ConsignorName: "ｶ)ｹﾝｼﾝｼﾖｳｼﾞ",
+			DebitDate:     "0427",
+			BankCode:      "2606",
+			BranchCode:    "010",
+			AccountType:   types.AccountTypeRegular,
+			AccountNumber: "1111111",
+		},
+		Data: []types.DebitData{
+			{BankCode: "2606", BranchCode: "020", AccountType: types.AccountTypeRegular, AccountNumber: "2222222",
+				AccountName: "ｹﾝｼﾝ ﾀﾛｳ", Amount: 3000, NewCode: types.CodeOther, CustomerNumber: "00000000000000000001",
+				ResultCode: types.ResultCodeTransferred},
+			{BankCode: "2606", BranchCode: "030", AccountType: types.AccountTypeSavings, AccountNumber: "3333333",
+				AccountName: "ｹﾝｼﾝ ﾊﾅｺ", Amount: 5000, NewCode: types.CodeOther, CustomerNumber: "00000000000000000002",
+				ResultCode: types.ResultCodeInsufficientFunds},
+		},
+		Trailer: types.DebitTrailer{
+			TotalCount: 2, TotalAmount: 8000,
+			TransferredCount: 1, TransferredAmount: 3000,
+			FailedCount: 1, FailedAmount: 5000,
+		},
+	}
+
+	var file bytes.Buffer
+	if err := WriteDebit(&file, []types.DebitGroup{group}, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	written := file.String()
+
+	result, err := ParseDebit(strings.NewReader(written), WithEncoding(types.EncodingUTF8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Groups) != 1 {
+		t.Fatalf("expected one group, got %+v", result)
+	}
+	parsed := result.Groups[0]
+	if parsed.Header.CategoryCode != types.CategoryCodeDebit || parsed.Header.ConsignorCode != "1234567890" ||
+		parsed.Header.DebitDate != "0427" || parsed.Trailer.FailedAmount != 5000 {
+		t.Fatalf("unexpected group: %+v", parsed)
+	}
+	if code := parsed.Data[1].ResultCode; code != types.ResultCodeInsufficientFunds || code.Description() != "資金不足" {
+		t.Fatalf("expected 資金不足, got %d %s", code, code.Description())
+	}
+
+	// The result totals don't match the result codes
+	mismatch := strings.Replace(written, "8000002000000008000000001000000003000000001000000005000",
+		"8000002000000008000000002000000008000000000000000000000", 1)
+	_, err = ParseDebit(strings.NewReader(mismatch), WithEncoding(types.EncodingUTF8))
+	var parseError *types.ParseError
+	if !errors.As(err, &parseError) || parseError.Field != "TransferredCount" {
+		t.Fatalf("expected error on TransferredCount, got %v", err)
+	}
+
+	if _, err := Parse(strings.NewReader(written)); !errors.As(err, &parseError) || parseError.Field != "CategoryCode" {
+		t.Fatalf("expected error on CategoryCode, got %v", err)
+	}
+
+	// Request files set the result codes to 0, which are not counted as transferred, and some leave them blank
+	request := types.DebitGroup{Header: group.Header, Data: append([]types.DebitData(nil), group.Data...)}
+	for i := range request.Data {
+		request.Data[i].ResultCode = 0
+	}
+	request.Data[1].ResultCode = types.ResultCodeBlank
+	file.Reset()
+	if err := WriteDebit(&file, []types.DebitGroup{request}, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(file.String(), "00000000000000000001"+"0") || !strings.Contains(file.String(), "00000000000000000002"+" ") {
+		t.Fatalf("expected result codes 0 and blank, got %q", file.String())
+	}
+	result, err = ParseDebit(strings.NewReader(file.String()), WithEncoding(types.EncodingUTF8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed = result.Groups[0]
+	if parsed.Data[0].ResultCode != types.ResultCodeTransferred || parsed.Data[1].ResultCode != types.ResultCodeBlank ||
+		parsed.Trailer.TransferredCount != 0 || parsed.Trailer.TotalAmount != 8000 {
+		t.Fatalf("unexpected request group: %+v", parsed)
+	}
+	if code := types.ResultCode(8); code != types.ResultCodeStoppedByConsignor || code.Code() != "8" || types.ParseResultCode("5") != types.ResultCodeUndefined {
+		t.Fatalf("expected result codes to be their digits, got %d", code)
+	}
+}
+
+func TestParseNotification(t *testing.T) {
+	// Fields of the 全銀協 layout, in order
+	header := func(accountName string) string {
+		return "1" + "01" + "0" + "260501" + "260501" + "260501" + "2606" + zengintest.Pad("ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ", 15) + "010" + zengintest.Pad("ﾎﾝﾃﾝ", 15) +
+			zengintest.Pad("", 3) + "1" + "0001234567" + zengintest.Pad(accountName, 40) + zengintest.Pad("", 87)
+	}
+	data := func(inquiryNumber, amount, senderCode, senderName, bankName, branchName, cancelled, edi string) string {
+		return "2" + inquiryNumber + "260501" + "260501" + amount + "0000000000" + senderCode + zengintest.Pad(senderName, 48) +
+			zengintest.Pad(bankName, 15) + zengintest.Pad(branchName, 15) + cancelled + zengintest.Pad(edi, 20) + zengintest.Pad("", 52)
+	}
+	records := []string{
+		header("ｶ)ｹﾝｼﾝｼﾖｳｼﾞ"),
+		data("000001", "0000010000", "0000000001", "ｹﾝｼﾝ ﾀﾛｳ", "ｻﾝﾉﾐﾔｷﾞﾝｺｳ", "ﾎﾝﾃﾝ", " ", "INV001"),
+		data("000002", "0000002500", zengintest.Pad("", 10), "ｹﾝｼﾝ ﾊﾅｺ", "", "", "1", ""),
+		"8" + "000001" + "000000010000" + "000001" + "000000002500" + zengintest.Pad("", 163),
+		zengintest.Pad("9", 200),
+	}
+	for i, r := range records {
+		if n := len([]rune(r)); n != 200 {
+			t.Fatalf("record %d is %d characters long", i+1, n)
+		}
+	}
+
+	file := zengintest.ShiftJIS(t, strings.Join(records, ""))
+	result, err := ParseNotification(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Groups) != 1 || len(result.Groups[0].Data) != 2 {
+		t.Fatalf("expected one group with two credits, got %+v", result)
+	}
+	group := result.Groups[0]
+	if group.Header.AccountNumber != "0001234567" || group.Header.AccountName != zengintest.Pad("ｶ)ｹﾝｼﾝｼﾖｳｼﾞ", 40) ||
+		group.Header.AccountingDateTo != "260501" {
+		t.Fatalf("unexpected header: %+v", group.Header)
+	}
+	credit := group.Data[0]
+	if credit.InquiryNumber != "000001" || credit.AccountingDate != "260501" || credit.Amount != 10000 || credit.SenderCode != "0000000001" ||
+		credit.SenderName != zengintest.Pad("ｹﾝｼﾝ ﾀﾛｳ", 48) || credit.SenderBranchName != zengintest.Pad("ﾎﾝﾃﾝ", 15) ||
+		credit.EdiInformation != zengintest.Pad("INV001", 20) || credit.Cancelled {
+		t.Fatalf("unexpected credit: %+v", credit)
+	}
+	if !group.Data[1].Cancelled || group.Trailer.CancelledAmount != 2500 {
+		t.Fatalf("expected a cancelled credit, got %+v", group)
+	}
+
+	records[3] = zengintest.Pad("8"+"000002"+"000000012500"+"000000"+"000000000000", 200)
+	// Records are also read one per line
+	file = zengintest.ShiftJIS(t, zengintest.File(records...))
+	_, err = ParseNotification(strings.NewReader(file))
+	var parseError *types.ParseError
+	if !errors.As(err, &parseError) || parseError.Field != "TotalCount" || parseError.Record != 4 {
+		t.Fatalf("expected error on TotalCount of record 4, got %v", err)
+	}
+}
+
+func TestParseStatement(t *testing.T) {
+	header := func(accountNumber, balance string) string {
+		return zengintest.Pad("1030"+"260501"+"260501"+"260501"+"2606"+zengintest.Pad("ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ", 15)+"010"+zengintest.Pad("ﾎﾝﾃﾝ", 15)+"   "+"1"+accountNumber+
+			zengintest.Pad("ｶ)ｹﾝｼﾝｼﾖｳｼﾞ", 40)+balance[0:1]+"1"+balance[1:], 200)
+	}
+	data := func(entryType, transactionType, amount, description string) string {
+		return zengintest.Pad("2"+"00000001"+"260501"+"260501"+entryType+transactionType+amount+"000000000000"+"      "+"      "+" "+"       "+"   "+
+			"          "+zengintest.Pad("", 48)+zengintest.Pad("", 15)+zengintest.Pad("", 15)+zengintest.Pad(description, 20), 200)
+	}
+	records := []string{
+		header("0001234567", "100000000100000"),
+		data("1", "11", "000000050000", "ﾌﾘｺﾐ ｹﾝｼﾝ ﾀﾛｳ"),
+		data("2", "14", "000000030000", "ﾃﾞﾝｷﾀﾞｲ"),
+		zengintest.Pad("8"+"000001"+"0000000050000"+"000001"+"0000000030000"+"1"+"00000000120000"+"0000002", 200),
+		// An overdrawn account without transactions
+		header("0007654321", "200000000005000"),
+		zengintest.Pad("8"+"000000"+"0000000000000"+"000000"+"0000000000000"+"2"+"00000000005000"+"0000000", 200),
+		zengintest.Pad("9", 200),
+	}
+
+	file := zengintest.ShiftJIS(t, zengintest.File(records...))
+	result, err := ParseStatement(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Groups) != 2 {
+		t.Fatalf("expected two accounts, got %+v", result)
+	}
+	first := result.Groups[0]
+	if first.Header.BalanceBefore != 100000 || first.Trailer.BalanceAfter != 120000 || len(first.Data) != 2 {
+		t.Fatalf("unexpected statement: %+v", first)
+	}
+	if d := first.Data[1]; d.EntryType != types.EntryTypeWithdrawal || d.TransactionType.Description() != "振替" ||
+		strings.TrimSpace(d.Description) != "ﾃﾞﾝｷﾀﾞｲ" {
+		t.Fatalf("unexpected withdrawal: %+v", d)
+	}
+	if second := result.Groups[1]; second.Header.BalanceBefore != -5000 || second.Header.BalanceBeforeOverdraft != "2" ||
+		second.Trailer.BalanceAfter != -5000 || second.Trailer.BalanceAfterOverdraft != "2" {
+		t.Fatalf("expected an overdrawn balance, got %+v", second)
+	}
+
+	records[3] = zengintest.Pad("8"+"000001"+"0000000050000"+"000001"+"0000000030000"+"1"+"00000000130000"+"0000002", 200)
+	file = zengintest.ShiftJIS(t, zengintest.File(records...))
+	_, err = ParseStatement(strings.NewReader(file))
+	var parseError *types.ParseError
+	if !errors.As(err, &parseError) || parseError.Field != "BalanceAfter" {
+		t.Fatalf("expected error on BalanceAfter, got %v", err)
+	}
+
+	records[3] = zengintest.Pad("8"+"000001"+"0000000050000"+"000001"+"0000000030000"+"1"+"00000000120000"+"0000002", 200)
+	records[4] = header("0007654321", "300000000005000")
+	file = zengintest.ShiftJIS(t, zengintest.File(records...))
+	_, err = ParseStatement(strings.NewReader(file))
+	if !errors.As(err, &parseError) || parseError.Field != "BalanceBeforeOverdraft" || parseError.Start != 113 {
+		t.Fatalf("expected error on BalanceBeforeOverdraft, got %v", err)
+	}
+
+	// Other formats such as 預金残高報告 are rejected on their 種別コード
+	records[0] = "104" + records[0][3:]
+	file = zengintest.ShiftJIS(t, zengintest.File(records...))
+	if _, err = ParseStatement(strings.NewReader(file)); !errors.As(err, &parseError) || parseError.Field != "CategoryCode" {
+		t.Fatalf("expected error on CategoryCode, got %v", err)
+	}
+}
+
+func TestPayroll(t *testing.T) {
+	header := `17110110999999ｶ)ｹﾝｼﾝｼﾖｳｼﾞ                             02242606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    010ﾎﾝﾃﾝ           20999999                 `
+	data := `22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00002500000                     %s       `
+	input := header + "\n" + fmt.Sprintf(data, " ") + "\n8000001000000250000\n9"
+
+	groups, err := ParseGroups(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := groups[0].Header
+	if parsed.CategoryCode != types.CategoryCodePayment || parsed.RawCategoryCode != "71" ||
+		parsed.CompanyCode() != "0110999999" || strings.TrimSpace(parsed.CompanyName()) != "ｶ)ｹﾝｼﾝｼﾖｳｼﾞ" {
+		t.Fatalf("unexpected header: %+v", parsed)
+	}
+
+	var file bytes.Buffer
+	if err := Write(&file, groups, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(file.String(), "171") {
+		t.Fatalf("expected 種別コード 71 to be written back, got %q", file.String()[0:3])
+	}
+
+	var parseError *types.ParseError
+	input = strings.Replace(header, "0110999999", "011099999A", 1) + "\n" + fmt.Sprintf(data, " ") + "\n8000001000000250000\n9"
+	if _, err := Parse(strings.NewReader(input)); !errors.As(err, &parseError) || parseError.FieldJa != "会社コード" {
+		t.Fatalf("expected error on 会社コード, got %v", err)
+	}
+	input = header + "\n" + fmt.Sprintf(data, "Y") + "\n8000001000000250000\n9"
+	if _, err := Parse(strings.NewReader(input)); !errors.As(err, &parseError) || parseError.Field != "EdiPresent" {
+		t.Fatalf("expected error on EdiPresent, got %v", err)
+	}
+}
+
+func TestExtra(t *testing.T) {
+	var data types.Data
+	if err := data.SetEdiInformation("マイツキブン"); err != nil {
+		t.Fatal(err)
+	}
+	if data.Extra != "ﾏｲﾂｷﾌﾞﾝ" || !data.EdiPresent {
+		t.Fatalf("expected narrowed EDI information, got %q", data.Extra)
+	}
+	if _, _, err := data.CustomerCodes(); err == nil {
+		t.Fatal("expected error for customer codes of EDI information")
+	}
+
+	if err := data.SetCustomerCodes("0000012345", ""); err != nil {
+		t.Fatal(err)
+	}
+	group := types.Group{
+		Header: types.Header{CategoryCode: types.CategoryCodeCombination, SenderCode: "0110999999", SenderName: "KENSHIN",
+			TransferDate: "0224", SenderBankCode: "2606", SenderBranchCode: "010", SenderAccountType: types.AccountTypeRegular,
+			SenderAccountNumber: "0999999"},
+		Data: []types.Data{{RecipientBankCode: "2606", RecipientBranchCode: "020", RecipientAccountType: types.AccountTypeRegular,
+			RecipientAccountNumber: "9876543", RecipientName: "KENSHIN SHOJI", Amount: 1, Extra: data.Extra, EdiPresent: data.EdiPresent}},
+	}
+	var file bytes.Buffer
+	if err := Write(&file, []types.Group{group}, types.EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	transfers, err := Parse(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code1, code2, err := transfers[0].CustomerCodes()
+	if err != nil || code1 != "0000012345" || code2 != "" {
+		t.Fatalf("expected customer codes 0000012345 and blank, got %q %q %v", code1, code2, err)
+	}
+	if _, err := transfers[0].EdiInformation(); err == nil {
+		t.Fatal("expected error for EDI information of customer codes")
+	}
+
+	if err := data.SetCustomerCodes("12345", ""); err == nil {
+		t.Fatal("expected error for a short customer code")
+	}
+}
+
+func TestBankDirectory(t *testing.T) {
+	directory, err := bankdir.LoadCSV(strings.NewReader(`bank_code,branch_code,bank_name,bank_kana,branch_name,branch_kana
+2606,010,兵庫県信用組合,ヒヨウゴケンシンクミ,本店,ホンテン
+2606,020,兵庫県信用組合,ヒヨウゴケンシンクミ,兵庫,ヒヨウゴ
+2606,030,兵庫県信用組合,ヒヨウゴケンシンクミ,三宮,サンノミヤ
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999`
+	input := header + `
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020                   19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              29999999ｹﾝｼﾝ ﾊﾅｺ                      00000000020                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    010ｷﾀﾉ                29999999ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030                    0
+8000003000000000006
+9`
+
+	result, err := ParseWithOptions(strings.NewReader(input), WithBankDirectory(directory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := result.Transfers[0].SenderBankName; name != "ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ" {
+		t.Fatalf("expected blank sender bank name to be filled in, got %q", name)
+	}
+	if name := result.Transfers[0].RecipientBranchName; name != "ﾋﾖｳｺﾞ" {
+		t.Fatalf("expected blank branch name to be filled in, got %q", name)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Field != "RecipientBranchName" || result.Warnings[0].Record != 4 {
+		t.Fatalf("expected a warning for ｷﾀﾉ, got %v", result.Warnings)
+	}
+
+	input = strings.Replace(input, "030ｻﾝﾉﾐﾔ", "040ｻﾝﾉﾐﾔ", 1)
+	_, err = ParseWithOptions(strings.NewReader(input), WithBankDirectory(directory))
+	var parseError *types.ParseError
+	if !errors.As(err, &parseError) || parseError.Field != "RecipientBranchCode" {
+		t.Fatalf("expected error on RecipientBranchCode, got %v", err)
+	}
+
+	// The embedded directory is partial and lists banks only: unknown bank codes are warnings
+	result, err = ParseWithOptions(strings.NewReader(input), WithBankDirectory(bankdir.MajorBanks()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Transfers) != 3 || len(result.Warnings) != 4 || result.Warnings[0].Field != "SenderBankCode" {
+		t.Fatalf("expected a warning for every record of bank 2606, got %v", result.Warnings)
+	}
+	// A loaded directory is complete
+	input = strings.ReplaceAll(input, "22606", "20005")
+	_, err = ParseWithOptions(strings.NewReader(input), WithBankDirectory(directory))
+	if !errors.As(err, &parseError) || parseError.Field != "RecipientBankCode" {
+		t.Fatalf("expected error on RecipientBankCode, got %v", err)
+	}
+}
+
+func TestEncoderSanitize(t *testing.T) {
+	header := types.Header{CategoryCode: types.CategoryCodeCombination, SenderCode: "0110999999", SenderName: "けんしん たろう",
+		TransferDate: "0224", SenderBankCode: "2606", SenderBranchCode: "010", SenderAccountType: types.AccountTypeRegular,
+		SenderAccountNumber: "0999999"}
+	data := []types.Data{{RecipientBankCode: "2606", RecipientBranchCode: "020", RecipientAccountType: types.AccountTypeRegular,
+		RecipientAccountNumber: "9876543", RecipientName: "ケンシン　ショウジ", Amount: 1}}
+
+	var file bytes.Buffer
+	encoder := NewEncoder(&file, types.EncodingShiftJIS)
+	if err := encoder.Encode(header, data, nil); err == nil {
+		t.Fatal("expected error for full-width names")
+	}
+	encoder.SetSanitize(true)
+	if err := encoder.Encode(header, data, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	transfers, err := Parse(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfers[0].SenderName != "ｹﾝｼﾝ ﾀﾛｳ" || transfers[0].RecipientName != "ｹﾝｼﾝ ｼﾖｳｼﾞ" {
+		t.Fatalf("expected sanitized names, got %q and %q", transfers[0].SenderName, transfers[0].RecipientName)
+	}
+}
+
+func TestTransferDate(t *testing.T) {
+	header := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                %s2606               010               20999999                 `
+	data := `22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        `
+	file := func(date string) io.Reader {
+		return strings.NewReader(fmt.Sprintf(header, date) + "\n" + data + "\n8000001000000000001\n9")
+	}
+	jst := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		date      string
+		reference time.Time
+		expected  time.Time
+	}{
+		{"0224", time.Date(2026, 2, 1, 15, 0, 0, 0, jst), time.Date(2026, 2, 24, 0, 0, 0, 0, jst)},
+		{"0224", time.Date(2026, 2, 24, 15, 0, 0, 0, jst), time.Date(2026, 2, 24, 0, 0, 0, 0, jst)},
+		{"0105", time.Date(2026, 12, 28, 0, 0, 0, 0, jst), time.Date(2027, 1, 5, 0, 0, 0, 0, jst)},
+	}
+	for _, tt := range tests {
+		result, err := ParseWithOptions(file(tt.date), WithReferenceDate(tt.reference), WithCalendar(calendar.Japan()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := result.Transfers[0].ResolvedTransferDate; !actual.Equal(tt.expected) {
+			t.Errorf("%s from %v: expected %v, got %v", tt.date, tt.reference, tt.expected, actual)
+		}
+	}
+
+	reference := WithReferenceDate(time.Date(2026, 2, 1, 0, 0, 0, 0, jst))
+	var parseError *types.ParseError
+	if _, err := ParseWithOptions(file("0223"), reference, WithCalendar(calendar.Japan())); !errors.Is(err, types.ErrNotBusinessDay) ||
+		!errors.As(err, &parseError) || parseError.Field != "TransferDate" {
+		t.Fatalf("expected 天皇誕生日 not to be a business day, got %v", err)
+	}
+	if _, err := ParseWithOptions(file("0224"), WithReferenceDate(time.Date(2006, 2, 1, 0, 0, 0, 0, jst)), WithCalendar(calendar.Japan())); !errors.Is(err, types.ErrDateNotCovered) ||
+		!errors.As(err, &parseError) || parseError.Field != "TransferDate" {
+		t.Fatalf("expected 2006 not to be covered by the calendar, got %v", err)
+	}
+	if _, err := ParseWithOptions(file("0224"), WithCalendar(calendar.Japan())); !errors.Is(err, types.ErrNoReferenceDate) {
+		t.Fatalf("expected a calendar without a reference date to be an error, got %v", err)
+	}
+	if _, err := ParseWithOptions(file("0229"), reference); !errors.As(err, &parseError) || parseError.Field != "TransferDate" {
+		t.Fatalf("expected error for February 29 of a common year, got %v", err)
+	}
+	if date, err := types.ResolveDate("0229", time.Date(2027, 6, 1, 0, 0, 0, 0, jst)); err != nil || date.Year() != 2028 {
+		t.Fatalf("expected February 29 2028, got %v, %v", date, err)
+	}
+
+}
+
+func TestEncoderCalendar(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	japan := calendar.Japan()
+
+	header := types.Header{CategoryCode: types.CategoryCodeCombination, SenderCode: "0110999999", SenderName: "ｹﾝｼﾝ ﾀﾛｳ",
+		SenderBankCode: "2606", SenderBranchCode: "010", SenderAccountType: types.AccountTypeChecking, SenderAccountNumber: "0999999",
+		ResolvedTransferDate: japan.AddBusinessDays(time.Date(2026, 9, 18, 0, 0, 0, 0, jst), 1)}
+	data := []types.Data{{RecipientBankCode: "2606", RecipientBranchCode: "020", RecipientAccountType: types.AccountTypeRegular,
+		RecipientAccountNumber: "9876543", RecipientName: "ｹﾝｼﾝ ｼﾖｳｼﾞ", Amount: 10}}
+	var file bytes.Buffer
+	encoder := NewEncoder(&file, types.EncodingUTF8)
+	encoder.SetCalendar(japan)
+	if err := encoder.Encode(header, data, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(file.String(), "ｹﾝｼﾝ ﾀﾛｳ                                0924") {
+		t.Fatalf("expected transfer date 0924, got %q", file.String())
+	}
+	header.ResolvedTransferDate = time.Date(2026, 9, 22, 0, 0, 0, 0, jst)
+	if err := encoder.Encode(header, data, nil); !errors.Is(err, types.ErrNotBusinessDay) {
+		t.Fatalf("expected 国民の休日 not to be a business day, got %v", err)
+	}
+	header.TransferDate, header.ResolvedTransferDate = "0925", time.Date(2026, 9, 24, 0, 0, 0, 0, jst)
+	if err := encoder.Encode(header, data, nil); err == nil || !strings.Contains(err.Error(), "2026-09-24") {
+		t.Fatalf("expected a transfer date other than the resolved one to be an error, got %v", err)
+	}
+	header.TransferDate, header.ResolvedTransferDate = "0924", time.Time{}
+	if err := encoder.Encode(header, data, nil); !errors.Is(err, types.ErrNoReferenceDate) {
+		t.Fatalf("expected an unresolved transfer date to be an error, got %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	input := strings.Join([]string{
+		"12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999                 ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0        ",
+		"22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              11234567ｹﾝｼﾝ ｼﾞﾛｳ                     00000000030ﾏｲﾂｷﾌﾞﾝ             0Y       ",
+		"8000002000000000004                                                                                                     ",
+		"9                                                                                                                       ",
+	}, "\r\n")
+
+	var output bytes.Buffer
+	if err := ToJSON(strings.NewReader(input), &output); err != nil {
+		t.Fatal(err)
+	}
+	var transfers []map[string]any
+	if err := json.Unmarshal(output.Bytes(), &transfers); err != nil {
+		t.Fatalf("invalid JSON %q: %v", output.String(), err)
+	}
+	expected := map[string]any{
+		"category_code": "21", "category": "combination", "encoding_type": "1", "sender_code": "0110999999",
+		"sender_name": "ｹﾝｼﾝ ﾀﾛｳ", "transfer_date": "0224", "sender_bank_code": "2606", "sender_bank_name": "",
+		"sender_branch_code": "010", "sender_branch_name": "", "sender_account_type": "checking", "sender_account_number": "0999999",
+		"recipient_bank_code": "2606", "recipient_bank_name": "ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ", "recipient_branch_code": "020",
+		"recipient_branch_name": "ﾋﾖｳｺﾞ", "exchange_office_code": "", "recipient_account_type": "regular",
+		"recipient_account_number": "9876543", "recipient_name": "ｹﾝｼﾝ ｼﾖｳｼﾞ", "amount": float64(1), "new_code": "other",
+		"extra": "", "edi_present": false, "transfer_category": "0", "total_count": float64(2), "total_amount": float64(4),
+	}
+	if len(transfers) != 2 || !reflect.DeepEqual(transfers[0], expected) {
+		t.Fatalf("expected %v, got %v", expected, transfers)
+	}
+
+	output.Reset()
+	if err := ToNDJSON(strings.NewReader(input), &output); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "total_count") || !strings.Contains(lines[1], `"extra":"ﾏｲﾂｷﾌﾞﾝ","edi_present":true`) {
+		t.Fatalf("expected a line per transfer without totals, got %q", output.String())
+	}
+
+	groups, err := ParseGroups(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output.Reset()
+	encoder := NewNDJSONEncoder(&output)
+	encoder.SetJapanese(true)
+	if err := encoder.EncodeGroup(groups[0]); err != nil {
+		t.Fatal(err)
+	}
+	var group struct {
+		Header  map[string]any   `json:"header"`
+		Data    []map[string]any `json:"data"`
+		Trailer map[string]any   `json:"trailer"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &group); err != nil {
+		t.Fatal(err)
+	}
+	if group.Header["category"] != "総合振込" || group.Data[1]["recipient_account_type"] != "普通" || group.Trailer["total_amount"] != float64(4) {
+		t.Fatalf("unexpected group %q", output.String())
+	}
+
+	output.Reset()
+	if err := ToJSON(strings.NewReader(input[:len(input)-240]+"\r\n9"), &output); err == nil || output.Len() != 0 {
+		t.Fatalf("expected nothing written for an invalid file, got %q", output.String())
+	}
+}
+
+func TestTableWriter(t *testing.T) {
+	input := `12110110999999ｹﾝｼﾝ ﾀﾛｳ                                02242606               010               20999999
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    020ﾋﾖｳｺﾞ              19876543ｹﾝｼﾝ ｼﾖｳｼﾞ                    00000000010                    0
+22606ﾋﾖｳｺﾞｹﾝｼﾝｸﾐ    030ｻﾝﾉﾐﾔ              49999999ｹﾝｼﾝ ﾊﾅｺ, ｼﾞﾕﾆｱ               00000000020                    0
+8000002000000000003
+9`
+	transfers, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	writer := NewCSVWriter(&output)
+	if err := writer.Write(transfers); err != nil {
+		t.Fatal(err)
+	}
+	expected := "SenderName,RecipientBankCode,RecipientBranchCode,RecipientAccountType,RecipientAccountNumber,RecipientName,Amount\n" +
+		"ｹﾝｼﾝ ﾀﾛｳ,2606,020,1,9876543,ｹﾝｼﾝ ｼﾖｳｼﾞ,1\n" +
+		"ｹﾝｼﾝ ﾀﾛｳ,2606,030,4,9999999,\"ｹﾝｼﾝ ﾊﾅｺ, ｼﾞﾕﾆｱ\",2\n"
+	if output.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, output.String())
+	}
+
+	output.Reset()
+	writer = NewTSVWriter(&output)
+	if err := writer.SetColumns("TransferDate", "RecipientAccountType", "Amount", "TotalAmount"); err != nil {
+		t.Fatal(err)
+	}
+	writer.SetJapanese(true)
+	writer.SetAccountTypeLabels(true)
+	writer.SetEncoding(types.EncodingUTF8, true)
+	if err := writer.Write(transfers); err != nil {
+		t.Fatal(err)
+	}
+	expected = "\uFEFF振込指定日\t受取人預金種目\t振込金額\t合計金額\n0224\t普通\t1\t3\n0224\t貯蓄\t2\t3\n"
+	if output.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, output.String())
+	}
+
+	output.Reset()
+	writer = NewCSVWriter(&output)
+	writer.SetJapanese(true)
+	writer.SetEncoding(types.EncodingShiftJIS, false)
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().String(output.String())
+	if err != nil || decoded != "振込依頼人名,被仕向金融機関番号,被仕向支店番号,受取人預金種目,受取人口座番号,受取人名,振込金額\n" {
+		t.Fatalf("expected a Shift-JIS header row, got %q", decoded)
+	}
+
+	if err := writer.SetColumns("Dummy"); err == nil {
+		t.Fatal("expected error for unknown column, got nil")
+	}
+	if len(Columns()) != 27 {
+		t.Fatalf("expected every field as a column, got %v", Columns())
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	input := "\uFEFF銀行コード,支店コード,預金種目,口座番号,受取人名,金額,EDI情報\n" +
+		"2606,20,普通,9876543,ケンシン　ショウジ,\"10,000円\",\n" +
+		"２６０６,030,当座,1234567,(ｶ)ｹﾝｼﾝ,１２３,ﾏｲﾂｷﾌﾞﾝ\n" +
+		",,,,,,\n"
+	header := types.Header{SenderCode: "0110999999", SenderName: "ｹﾝｼﾝ ﾀﾛｳ", TransferDate: "0224",
+		SenderBankCode: "2606", SenderBranchCode: "010", SenderAccountType: types.AccountTypeChecking, SenderAccountNumber: "0999999"}
+
+	var file bytes.Buffer
+	if err := ImportCSV(strings.NewReader(input), header, &file, types.EncodingShiftJIS); err != nil {
+		t.Fatal(err)
+	}
+	transfers, err := Parse(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 2 || transfers[0].TotalAmount != 10123 || transfers[0].CategoryCode != types.CategoryCodeCombination {
+		t.Fatalf("expected 2 transfers with computed totals, got %+v", transfers)
+	}
+	first, second := transfers[0], transfers[1]
+	if first.RecipientBranchCode != "020" || first.RecipientName != "ｹﾝｼﾝ ｼﾖｳｼﾞ" || first.Amount != 10000 {
+		t.Errorf("unexpected first transfer: %+v", first.Data)
+	}
+	if edi, _ := second.EdiInformation(); second.RecipientBankCode != "2606" || second.RecipientAccountType != types.AccountTypeChecking ||
+		second.Amount != 123 || edi != "ﾏｲﾂｷﾌﾞﾝ" {
+		t.Errorf("unexpected second transfer: %+v", second.Data)
+	}
+
+	reader := NewCSVReader(strings.NewReader("bank\tbranch\ttype\tnumber\tname\tamount\n" +
+		"2606\t020\tregular\t9876543\tｹﾝｼﾝ ｼﾖｳｼﾞ\t1\n" +
+		"2606\t020\t定期\t9876543\tｹﾝｼﾝ ｼﾖｳｼﾞ\t1\n" +
+		"2606\t020\t1\t9876543\tｹﾝｼﾝ ｼﾖｳｼﾞ\t-1\n"))
+	reader.SetComma('\t')
+	for field, column := range map[string]string{"RecipientBankCode": "bank", "RecipientBranchCode": "branch", "RecipientAccountType": "type",
+		"RecipientAccountNumber": "number", "RecipientName": "name", "Amount": "amount"} {
+		if err := reader.SetColumn(field, column); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = reader.Read()
+	var rowError *types.RowError
+	if !errors.As(err, &rowError) || rowError.Row != 3 || rowError.Field != "RecipientAccountType" {
+		t.Fatalf("expected error on the account type of row 3, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "row 4, column amount (Amount)") {
+		t.Fatalf("expected error on the amount of row 4, got %v", err)
+	}
+
+	_, err = NewCSVReader(strings.NewReader("銀行コード,支店コード,預金種目,口座番号,受取人名,金額,EDI情報\n" +
+		"2606,020,普通,9876543,ｹﾝｼﾝ ｼﾖｳｼﾞ,1,請求書番号\n")).Read()
+	if !errors.As(err, &rowError) || rowError.Column != "EDI情報" || rowError.Field != "EdiInformation" || rowError.Value != "請求書番号" {
+		t.Fatalf("expected error on the EDI情報 column, got %v", err)
+	}
+}